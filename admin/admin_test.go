@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeDB struct {
+	err error
+}
+
+func (p fakeDB) PingContext(context.Context) error {
+	return p.err
+}
+
+func (p fakeDB) Stats() sql.DBStats {
+	return sql.DBStats{OpenConnections: 3, InUse: 1, Idle: 2}
+}
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		err    error
+		expect int
+	}{
+		{name: "ヘルスチェック", path: "/healthz", expect: http.StatusOK},
+		{name: "データベースが利用可能", path: "/readyz", expect: http.StatusOK},
+		{name: "データベースが利用不可", path: "/readyz", err: errors.New("some error"), expect: http.StatusServiceUnavailable},
+		{name: "データベースが利用不可でもプロセスは生存", path: "/healthz", err: errors.New("some error"), expect: http.StatusOK},
+		{name: "接続プールの状態", path: "/dbstats", expect: http.StatusOK},
+		{name: "メトリクス", path: "/metrics", expect: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewHandler(fakeDB{err: tt.err}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.expect {
+				t.Errorf("Expect: %v, Got: %v", tt.expect, rec.Code)
+			}
+		})
+	}
+}
+
+func TestNewHandler_dbstats(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHandler(fakeDB{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dbstats", nil))
+
+	var got dbStats
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if got.OpenConnections != 3 || got.InUse != 1 || got.Idle != 2 {
+		t.Errorf("unexpected stats: %+v", got)
+	}
+}
+
+func TestNewHandler_withoutDB(t *testing.T) {
+	h := NewHandler(nil)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expect: %v, Got: %v", http.StatusOK, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dbstats", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expect: %v, Got: %v", http.StatusNotFound, rec.Code)
+	}
+}
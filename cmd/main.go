@@ -5,6 +5,9 @@ import (
 	"os"
 
 	"sample-grpc-server/cmd/migration"
+	"sample-grpc-server/cmd/secret"
+	"sample-grpc-server/cmd/seed"
+	"sample-grpc-server/cmd/serve"
 
 	"github.com/spf13/cobra"
 )
@@ -19,6 +22,9 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(migration.Cmd)
+	rootCmd.AddCommand(secret.Cmd)
+	rootCmd.AddCommand(seed.Cmd)
+	rootCmd.AddCommand(serve.Cmd)
 }
 
 func main() {
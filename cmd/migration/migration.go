@@ -3,65 +3,235 @@ package migration
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
 	cfg "sample-grpc-server/config"
 	"sample-grpc-server/database"
-	"sample-grpc-server/database/model"
+	"sample-grpc-server/database/migrations"
+	"sample-grpc-server/secret"
 
 	"github.com/spf13/cobra"
 	"github.com/uptrace/bun"
-	"github.com/uptrace/bun/dialect/mysqldialect"
+	"github.com/uptrace/bun/migrate"
 	"golang.org/x/xerrors"
 )
 
 var Cmd = &cobra.Command{
 	Use:          "migration",
+	Short:        "Manage database schema migrations",
 	SilenceUsage: false,
 	Run: func(cmd *cobra.Command, args []string) {
-		Start()
+		cmd.Help()
 	},
 }
 
-func openDB(ctx context.Context) (*sql.DB, error) {
-	cfg.LoadConfig()
+var upCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrator(cmd, true, func(ctx context.Context, m *migrate.Migrator) error {
+			group, err := m.Migrate(ctx)
+			if err != nil {
+				return xerrors.Errorf("failed to migrate: %v", err)
+			}
+
+			if group.IsZero() {
+				log.Println("there are no new migrations to run")
+				return nil
+			}
+
+			log.Printf("migrated to %s", group)
+			return nil
+		})
+	},
+}
+
+var downCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Roll back the last migration group",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrator(cmd, true, func(ctx context.Context, m *migrate.Migrator) error {
+			group, err := m.Rollback(ctx)
+			if err != nil {
+				return xerrors.Errorf("failed to roll back: %v", err)
+			}
+
+			if group.IsZero() {
+				log.Println("there are no groups to roll back")
+				return nil
+			}
+
+			log.Printf("rolled back %s", group)
+			return nil
+		})
+	},
+}
 
-	loc := time.FixedZone("Local", 9*60*60)
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show applied and pending migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrator(cmd, false, func(ctx context.Context, m *migrate.Migrator) error {
+			ms, err := m.MigrationsWithStatus(ctx)
+			if err != nil {
+				return xerrors.Errorf("failed to get migrations: %v", err)
+			}
+
+			for _, migration := range ms {
+				state := "pending"
+				if migration.IsApplied() {
+					state = fmt.Sprintf("applied (group #%d at %s)", migration.GroupID, migration.MigratedAt.Format(time.RFC3339))
+				}
+				fmt.Printf("%s\t%s\n", migration.Name, state)
+			}
+
+			missing, err := m.MissingMigrations(ctx)
+			if err != nil {
+				return xerrors.Errorf("failed to get missing migrations: %v", err)
+			}
+			for _, migration := range missing {
+				fmt.Printf("%s\tmissing (applied but not found)\n", migration.Name)
+			}
+
+			return nil
+		})
+	},
+}
 
-	dns := database.FormatDNS(loc)
+var createCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create up and down SQL migration files for every database driver",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := cmd.Flags().GetString("dir")
+		if err != nil {
+			return err
+		}
+
+		// ファイルを生成するだけなのでデータベースには接続しない
+		paths, err := migrations.Create(dir, args[0])
+		if err != nil {
+			return xerrors.Errorf("failed to create migration: %v", err)
+		}
+
+		for _, path := range paths {
+			log.Printf("created migration %s", path)
+		}
+
+		return nil
+	},
+}
+
+var resetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Roll back every migration and apply them again (drops all data)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		if !force {
+			return errors.New("reset drops all tables and data; pass --force to continue")
+		}
+
+		return withMigrator(cmd, true, func(ctx context.Context, m *migrate.Migrator) error {
+			for {
+				group, err := m.Rollback(ctx)
+				if err != nil {
+					return xerrors.Errorf("failed to roll back: %v", err)
+				}
+				if group.IsZero() {
+					break
+				}
+				log.Printf("rolled back %s", group)
+			}
+
+			group, err := m.Migrate(ctx)
+			if err != nil {
+				return xerrors.Errorf("failed to migrate: %v", err)
+			}
+
+			log.Printf("migrated to %s", group)
+			return nil
+		})
+	},
+}
+
+func init() {
+	resetCmd.Flags().Bool("force", false, "confirm dropping all tables")
+	createCmd.Flags().String("dir", "database/migrations", "migrations directory in the source tree that contains a directory for each driver")
+
+	for _, c := range []*cobra.Command{upCmd, downCmd, statusCmd, resetCmd} {
+		cfg.RegisterFlags(c.Flags())
+	}
 
-	db, err := sql.Open("mysql", dns)
+	Cmd.AddCommand(upCmd, downCmd, statusCmd, createCmd, resetCmd)
+}
+
+func openDB(ctx context.Context, c *cfg.Config) (*sql.DB, error) {
+	secrets, err := secret.NewProvider(c)
 	if err != nil {
-		return nil, xerrors.Errorf("failed to open database connection: %v", err)
+		return nil, err
 	}
 
-	if err := db.PingContext(ctx); err != nil {
-		return nil, xerrors.Errorf("failed to verify database connection: %v", err)
+	conn, err := database.NewConnector(ctx, c, secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	db := database.OpenDB(c, conn)
+
+	if err := database.WaitForConnection(ctx, db, c.GetDBConnectTimeout()); err != nil {
+		db.Close()
+		return nil, err
 	}
 
 	return db, nil
 }
 
-func Start() {
-	log.Println("started migration")
+// withMigrator はマイグレーション管理用のテーブルを作成してからfnを実行する。
+// lockがtrueの場合は実行中に他のプロセスが同時にマイグレーションできないようロックを取る
+func withMigrator(cmd *cobra.Command, lock bool, fn func(context.Context, *migrate.Migrator) error) error {
+	ctx := cmd.Context()
+
+	c, err := cfg.Load(cfg.Options{Flags: cmd.Flags()})
+	if err != nil {
+		return err
+	}
 
-	ctx := context.Background()
+	sqlDB, err := openDB(ctx, c)
+	if err != nil {
+		return err
+	}
 
-	sqlDB, err := openDB(ctx)
+	db := bun.NewDB(sqlDB, database.NewDialect(c.GetDBDriver()))
+	defer db.Close()
+
+	ms, err := migrations.For(db.Dialect().Name())
 	if err != nil {
-		log.Fatalf("failed to open database connection: %v", err)
+		return err
 	}
 
-	db := bun.NewDB(sqlDB, mysqldialect.New())
+	m := migrate.NewMigrator(db, ms)
+
+	if err := m.Init(ctx); err != nil {
+		return xerrors.Errorf("failed to initialize migration tables: %v", err)
+	}
 
-	if err := db.ResetModel(ctx,
-		(*model.User)(nil),
-		(*model.Session)(nil),
-		(*model.Article)(nil),
-	); err != nil {
-		log.Fatalf("failed to reset tables: %v", err)
+	if lock {
+		if err := m.Lock(ctx); err != nil {
+			return xerrors.Errorf("another migration is running: %v", err)
+		}
+		defer func() {
+			// 実行中のコンテキストがキャンセルされてもロックは確実に解放する
+			if err := m.Unlock(context.Background()); err != nil {
+				log.Printf("failed to release migration lock: %v", err)
+			}
+		}()
 	}
 
-	log.Println("completed migration")
+	return fn(ctx, m)
 }
@@ -0,0 +1,81 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"sample-grpc-server/secret"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage the encrypted secret file",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var keygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Print a new key for the encrypted secret file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := secret.GenerateKey()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(key)
+		return nil
+	},
+}
+
+var encryptCmd = &cobra.Command{
+	Use:     "encrypt",
+	Short:   "Encrypt a JSON object of secrets read from stdin",
+	Example: `  echo '{"db_password":"password"}' | secret encrypt --key-file secret.key -o secrets.enc`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keyFile, _ := cmd.Flags().GetString("key-file")
+		out, _ := cmd.Flags().GetString("output")
+
+		key, err := secret.LoadKey(keyFile)
+		if err != nil {
+			return err
+		}
+
+		data, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return xerrors.Errorf("failed to read secrets: %v", err)
+		}
+
+		values := make(map[string]string)
+		if err := json.Unmarshal(data, &values); err != nil {
+			return xerrors.Errorf("secrets must be a JSON object of strings: %v", err)
+		}
+
+		encrypted, err := secret.Encrypt(key, values)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(out, encrypted, 0o600); err != nil {
+			return xerrors.Errorf("failed to write secret file: %v", err)
+		}
+
+		log.Printf("encrypted %d secrets to %s", len(values), out)
+		return nil
+	},
+}
+
+func init() {
+	encryptCmd.Flags().String("key-file", "", "key file created by secret keygen")
+	encryptCmd.Flags().StringP("output", "o", "secrets.enc", "output file")
+	encryptCmd.MarkFlagRequired("key-file")
+
+	Cmd.AddCommand(keygenCmd, encryptCmd)
+}
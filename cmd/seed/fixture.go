@@ -0,0 +1,118 @@
+package seed
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+)
+
+type Fixture struct {
+	Users []UserFixture `json:"users" yaml:"users"`
+}
+
+type UserFixture struct {
+	Email    string           `json:"email" yaml:"email"`
+	Password string           `json:"password" yaml:"password"`
+	Articles []ArticleFixture `json:"articles" yaml:"articles"`
+}
+
+// ArticleFixture のExternalIDは再実行時の重複判定に使う。省略した場合はユーザー内の順番から決まる
+type ArticleFixture struct {
+	ExternalID  string  `json:"external_id" yaml:"external_id"`
+	Title       string  `json:"title" yaml:"title"`
+	Description *string `json:"description" yaml:"description"`
+	Text        string  `json:"text" yaml:"text"`
+}
+
+// LoadFixture は拡張子に応じてYAMLまたはJSONのフィクスチャを読み込む
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read fixture: %v", err)
+	}
+
+	var f Fixture
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &f)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &f)
+	default:
+		return nil, xerrors.Errorf("unsupported fixture format: %s", path)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse fixture %s: %v", path, err)
+	}
+
+	if err := f.validate(); err != nil {
+		return nil, xerrors.Errorf("invalid fixture %s: %v", path, err)
+	}
+
+	return &f, nil
+}
+
+func (f *Fixture) validate() error {
+	emails := make(map[string]bool, len(f.Users))
+
+	for i, u := range f.Users {
+		if u.Email == "" || u.Password == "" {
+			return fmt.Errorf("users[%d]: email and password are required", i)
+		}
+		if emails[u.Email] {
+			return fmt.Errorf("users[%d]: duplicated email %s", i, u.Email)
+		}
+		emails[u.Email] = true
+
+		ids := make(map[string]bool, len(u.Articles))
+		for j, a := range u.Articles {
+			if a.Title == "" {
+				return fmt.Errorf("users[%d].articles[%d]: title is required", i, j)
+			}
+			id := a.externalID(j)
+			if ids[id] {
+				return fmt.Errorf("users[%d].articles[%d]: duplicated external_id %s", i, j, id)
+			}
+			ids[id] = true
+		}
+	}
+
+	return nil
+}
+
+func (a ArticleFixture) externalID(index int) string {
+	if a.ExternalID != "" {
+		return a.ExternalID
+	}
+	return fmt.Sprintf("seed-%d", index+1)
+}
+
+// Synthetic は負荷試験用にusers人のユーザーとそれぞれarticles件の記事を生成する
+func Synthetic(users, articles int, password string) *Fixture {
+	f := &Fixture{Users: make([]UserFixture, 0, users)}
+
+	for i := 1; i <= users; i++ {
+		u := UserFixture{
+			Email:    fmt.Sprintf("seed-user-%05d@example.com", i),
+			Password: password,
+			Articles: make([]ArticleFixture, 0, articles),
+		}
+
+		for j := 1; j <= articles; j++ {
+			u.Articles = append(u.Articles, ArticleFixture{
+				ExternalID: fmt.Sprintf("seed-%05d", j),
+				Title:      fmt.Sprintf("Seed article %d", j),
+				Text:       fmt.Sprintf("# Seed article %d\n\nThis article was generated by the seed command for user %d.", j, i),
+			})
+		}
+
+		f.Users = append(f.Users, u)
+	}
+
+	return f
+}
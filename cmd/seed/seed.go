@@ -0,0 +1,226 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	cfg "sample-grpc-server/config"
+	"sample-grpc-server/database"
+	"sample-grpc-server/secret"
+	"sample-grpc-server/service"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+)
+
+const batchSize = 100
+
+var Cmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Register users and articles from fixtures or generate synthetic data",
+	Example: `  seed -f fixtures/development.yaml
+  seed --users 100 --articles 50`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files, _ := cmd.Flags().GetStringSlice("file")
+		users, _ := cmd.Flags().GetInt("users")
+		articles, _ := cmd.Flags().GetInt("articles")
+		password, _ := cmd.Flags().GetString("password")
+
+		if len(files) == 0 && users == 0 {
+			return errors.New("specify fixture files with --file or the number of synthetic users with --users")
+		}
+
+		var fixtures []*Fixture
+		for _, path := range files {
+			f, err := LoadFixture(path)
+			if err != nil {
+				return err
+			}
+			fixtures = append(fixtures, f)
+		}
+		if users > 0 {
+			fixtures = append(fixtures, Synthetic(users, articles, password))
+		}
+
+		c, err := cfg.Load(cfg.Options{Flags: cmd.Flags()})
+		if err != nil {
+			return err
+		}
+
+		secrets, err := secret.NewProvider(c)
+		if err != nil {
+			return err
+		}
+
+		conn, err := database.NewConnector(cmd.Context(), c, secrets)
+		if err != nil {
+			return err
+		}
+
+		db, err := database.NewDatabase(cmd.Context(), c, conn)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		s := NewSeeder(database.NewQuery(db), service.NewHash())
+		for _, f := range fixtures {
+			summary, err := s.Seed(cmd.Context(), f)
+			if err != nil {
+				return err
+			}
+			log.Printf("seeded users: %d created, %d skipped / articles: %d created, %d skipped",
+				summary.UsersCreated, summary.UsersSkipped, summary.ArticlesCreated, summary.ArticlesSkipped)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	Cmd.Flags().StringSliceP("file", "f", nil, "YAML or JSON fixture files")
+	Cmd.Flags().Int("users", 0, "number of synthetic users to generate")
+	Cmd.Flags().Int("articles", 10, "number of synthetic articles per user")
+	Cmd.Flags().String("password", "password", "password of synthetic users")
+
+	cfg.RegisterFlags(Cmd.Flags())
+}
+
+type Summary struct {
+	UsersCreated    int
+	UsersSkipped    int
+	ArticlesCreated int
+	ArticlesSkipped int
+}
+
+// Seeder はフィクスチャを登録する。メールアドレスとexternal_idで登録済みかを判定するため何度実行してもよい
+type Seeder struct {
+	db   database.Querier
+	hash service.Hasher
+
+	// 合成データは全員同じパスワードのため、ハッシュの計算を一度で済ませる
+	hashes map[string]string
+}
+
+func NewSeeder(db database.Querier, hash service.Hasher) *Seeder {
+	return &Seeder{
+		db:     db,
+		hash:   hash,
+		hashes: make(map[string]string),
+	}
+}
+
+func (s *Seeder) Seed(ctx context.Context, f *Fixture) (*Summary, error) {
+	summary := &Summary{}
+
+	for _, u := range f.Users {
+		userID, created, err := s.seedUser(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			summary.UsersCreated++
+		} else {
+			summary.UsersSkipped++
+		}
+
+		n, err := s.seedArticles(ctx, userID, u.Articles)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to seed articles of %s: %v", u.Email, err)
+		}
+		summary.ArticlesCreated += n
+		summary.ArticlesSkipped += len(u.Articles) - n
+	}
+
+	return summary, nil
+}
+
+func (s *Seeder) seedUser(ctx context.Context, u UserFixture) (int64, bool, error) {
+	user, err := s.db.Login(ctx, database.LoginParams{Email: u.Email})
+	if err == nil {
+		return user.UserID, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, xerrors.Errorf("failed to get user %s: %v", u.Email, err)
+	}
+
+	hashed, ok := s.hashes[u.Password]
+	if !ok {
+		if hashed, err = s.hash.CreateHash(u.Password); err != nil {
+			return 0, false, err
+		}
+		s.hashes[u.Password] = hashed
+	}
+
+	resp, err := s.db.SignUp(ctx, database.SignUpParams{Email: u.Email, Password: hashed})
+	if err != nil {
+		return 0, false, xerrors.Errorf("failed to create user %s: %v", u.Email, err)
+	}
+
+	return resp.UserID, true, nil
+}
+
+// seedArticles は未登録の記事だけを登録し、登録した件数を返す
+func (s *Seeder) seedArticles(ctx context.Context, userID int64, articles []ArticleFixture) (int, error) {
+	created := 0
+
+	for start := 0; start < len(articles); start += batchSize {
+		end := start + batchSize
+		if end > len(articles) {
+			end = len(articles)
+		}
+
+		ids := make([]string, 0, end-start)
+		for i := start; i < end; i++ {
+			ids = append(ids, articles[i].externalID(i))
+		}
+
+		existing, err := s.db.GetArticleExternalIDs(ctx, database.GetArticleExternalIDsParams{
+			UserID:      userID,
+			ExternalIDs: ids,
+		})
+		if err != nil {
+			return created, err
+		}
+
+		skip := make(map[string]bool, len(existing.ExternalIDs))
+		for _, id := range existing.ExternalIDs {
+			skip[id] = true
+		}
+
+		params := database.BatchCreateArticlesParams{UserID: userID}
+		for i := start; i < end; i++ {
+			a := articles[i]
+			if skip[a.externalID(i)] {
+				continue
+			}
+
+			params.Articles = append(params.Articles, database.BatchCreateArticle{
+				ExternalID:  sql.NullString{String: a.externalID(i), Valid: true},
+				Title:       a.Title,
+				Description: nullString(a.Description),
+				Text:        a.Text,
+			})
+		}
+
+		if len(params.Articles) == 0 {
+			continue
+		}
+
+		if _, err := s.db.BatchCreateArticles(ctx, params); err != nil {
+			return created, err
+		}
+		created += len(params.Articles)
+	}
+
+	return created, nil
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
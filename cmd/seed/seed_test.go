@@ -0,0 +1,105 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sample-grpc-server/database"
+	mock_database "sample-grpc-server/database/mock"
+	mock_service "sample-grpc-server/service/mock"
+
+	"github.com/golang/mock/gomock"
+	"golang.org/x/xerrors"
+)
+
+func TestLoadFixture(t *testing.T) {
+	t.Run("JSON", func(t *testing.T) {
+		f, err := LoadFixture("testdata/fixture.json")
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		articles := f.Users[0].Articles
+		if len(articles) != 2 || articles[0].Description == nil || *articles[0].Description != "description1" {
+			t.Errorf("unexpected articles: %+v", articles)
+		}
+		if articles[1].externalID(1) != "seed-2" {
+			t.Errorf("Expect: %v, Got: %v", "seed-2", articles[1].externalID(1))
+		}
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		f, err := LoadFixture("../../fixtures/development.yaml")
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if len(f.Users) == 0 {
+			t.Error("users should not be empty")
+		}
+	})
+
+	t.Run("メールアドレスの重複", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "fixture.yaml")
+		data := "users:\n  - {email: a@example.com, password: p}\n  - {email: a@example.com, password: p}\n"
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := LoadFixture(path); err == nil {
+			t.Error("err should not be nil")
+		}
+	})
+
+	t.Run("未対応の拡張子", func(t *testing.T) {
+		if _, err := LoadFixture("fixture.toml"); err == nil {
+			t.Error("err should not be nil")
+		}
+	})
+}
+
+func TestSeeder_Seed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	notFound := xerrors.Errorf("ユーザー取得: %w", sql.ErrNoRows)
+
+	f := Synthetic(2, 3, "password")
+
+	db := mock_database.NewMockQuerier(ctrl)
+	hash := mock_service.NewMockHasher(ctrl)
+
+	// 1人目は登録済み、2人目は未登録
+	db.EXPECT().Login(ctx, database.LoginParams{Email: f.Users[0].Email}).Return(&database.LoginResult{UserID: 1}, nil)
+	db.EXPECT().Login(ctx, database.LoginParams{Email: f.Users[1].Email}).Return(nil, notFound)
+	hash.EXPECT().CreateHash("password").Return("hashed", nil).Times(1)
+	db.EXPECT().SignUp(ctx, database.SignUpParams{Email: f.Users[1].Email, Password: "hashed"}).Return(&database.SignUpResult{UserID: 2}, nil)
+
+	db.EXPECT().GetArticleExternalIDs(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, p database.GetArticleExternalIDsParams) (*database.GetArticleExternalIDsResult, error) {
+			if p.UserID == 1 {
+				return &database.GetArticleExternalIDsResult{ExternalIDs: []string{"seed-00001", "seed-00002", "seed-00003"}}, nil
+			}
+			return &database.GetArticleExternalIDsResult{ExternalIDs: []string{"seed-00002"}}, nil
+		}).Times(2)
+	db.EXPECT().BatchCreateArticles(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, p database.BatchCreateArticlesParams) (*database.BatchCreateArticlesResult, error) {
+			if p.UserID != 2 || len(p.Articles) != 2 {
+				t.Errorf("unexpected params: %+v", p)
+			}
+			return &database.BatchCreateArticlesResult{ArticleIDs: []int64{1, 2}}, nil
+		})
+
+	summary, err := NewSeeder(db, hash).Seed(ctx, f)
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	expect := Summary{UsersCreated: 1, UsersSkipped: 1, ArticlesCreated: 2, ArticlesSkipped: 4}
+	if *summary != expect {
+		t.Errorf("Expect: %+v, Got: %+v", expect, *summary)
+	}
+}
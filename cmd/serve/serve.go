@@ -0,0 +1,357 @@
+package serve
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"sample-grpc-server/admin"
+	"sample-grpc-server/config"
+	"sample-grpc-server/database"
+	"sample-grpc-server/database/cache"
+	"sample-grpc-server/database/memory"
+	"sample-grpc-server/interceptor"
+	"sample-grpc-server/logger"
+	"sample-grpc-server/pb"
+	"sample-grpc-server/secret"
+	"sample-grpc-server/server"
+	"sample-grpc-server/service"
+	"sample-grpc-server/storage"
+	"sample-grpc-server/thumbnail"
+
+	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+	"github.com/uptrace/bun"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+var Cmd = &cobra.Command{
+	Use:          "serve",
+	Short:        "Start the gRPC server",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := config.Options{Flags: cmd.Flags()}
+
+		c, err := config.Load(opts)
+		if err != nil {
+			return err
+		}
+
+		store := config.NewStore(c)
+
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+
+		go func() {
+			if err := config.Watch(ctx, store, opts); err != nil {
+				logger.Warnf("config hot reload is disabled: %v", err)
+			}
+		}()
+
+		return Run(store)
+	},
+}
+
+func init() {
+	config.RegisterFlags(Cmd.Flags())
+}
+
+// Run はSIGTERMかSIGINTを受け取るまでサーバーを起動する。
+// 待ち受けるアドレスなどは起動時の設定を使い、ログレベルやレート制限などはstoreの最新の設定を参照する
+func Run(store *config.Store) error {
+	c := store.Load()
+
+	applyLogLevel(c)
+	store.OnChange(applyLogLevel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		qer     database.Querier
+		adminDB admin.DB
+		checker *database.HealthChecker
+	)
+	if c.GetStorage() == config.StorageMemory {
+		logger.Warnf("storing data in memory, all data will be lost when the server stops")
+		qer = memory.NewQuerier()
+	} else {
+		db, q, closeDB, err := openDatabase(ctx, c)
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+
+		qer, adminDB = q, db
+		checker = database.NewHealthChecker(db.DB, c.GetDBHealthInterval())
+
+		if c.GetCacheSize() > 0 {
+			qer = cache.NewQuerier(qer, cache.NewLRU(c.GetCacheSize()),
+				cache.WithSessionTTL(c.GetCacheSessionTTL()),
+				cache.WithArticleTTL(c.GetCacheArticleTTL()),
+			)
+		}
+	}
+
+	listeners := make([]net.Listener, 0, 2)
+	defer func() {
+		for _, ln := range listeners {
+			ln.Close()
+		}
+	}()
+
+	ln, err := net.Listen("tcp", c.GetListenAddr())
+	if err != nil {
+		return xerrors.Errorf("failed to listen: %v", err)
+	}
+	listeners = append(listeners, ln)
+
+	if c.GetUnixSocket() != "" {
+		ln, err := listenUnix(c.GetUnixSocket())
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, ln)
+	}
+
+	recoveryOpts := []grpc_recovery.Option{
+		grpc_recovery.WithRecoveryHandler(interceptor.RecoveryFunc),
+	}
+
+	blobs, err := storage.NewLocalBlobStore(c.GetAttachmentDir())
+	if err != nil {
+		return xerrors.Errorf("failed to initialize blob store: %v", err)
+	}
+
+	thumbnails := thumbnail.NewGenerator(qer, blobs, c.GetThumbnailSizes(), c.GetThumbnailWorkers())
+
+	limiter := interceptor.NewRateLimiter(func() (float64, int) {
+		c := store.Load()
+		return c.GetRateLimitRPS(), c.GetRateLimitBurst()
+	})
+
+	grpcMetrics := interceptor.NewMetrics()
+	serverMetrics := server.NewMetrics()
+	for _, m := range []prometheus.Collector{grpcMetrics, serverMetrics} {
+		if err := prometheus.Register(m); err != nil {
+			return xerrors.Errorf("failed to register metrics: %v", err)
+		}
+	}
+
+	// レート制限や認証で拒否したリクエストも数えるよう先頭に近い位置に置く。
+	// インターセプター自身のパニックでプロセスが落ちないようrecoveryを先頭に置き、
+	// ハンドラーのパニックも指標やログに残るよう末尾にも置く
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			grpc_recovery.UnaryServerInterceptor(recoveryOpts...),
+			grpc_ctxtags.UnaryServerInterceptor(),
+			interceptor.MetricsInterceptor(grpcMetrics),
+			interceptor.LoggingInterceptor(),
+			interceptor.RateLimitInterceptor(limiter),
+			interceptor.AuthInterceptor(qer),
+			grpc_recovery.UnaryServerInterceptor(recoveryOpts...),
+		),
+		grpc.ChainStreamInterceptor(
+			grpc_recovery.StreamServerInterceptor(recoveryOpts...),
+			grpc_ctxtags.StreamServerInterceptor(),
+			interceptor.MetricsStreamInterceptor(grpcMetrics),
+			interceptor.LoggingStreamInterceptor(),
+			interceptor.RateLimitStreamInterceptor(limiter),
+			interceptor.AuthStreamInterceptor(qer),
+			grpc_recovery.StreamServerInterceptor(recoveryOpts...),
+		),
+	}
+
+	if c.GetTLSCertFile() != "" {
+		creds, err := credentials.NewServerTLSFromFile(c.GetTLSCertFile(), c.GetTLSKeyFile())
+		if err != nil {
+			return xerrors.Errorf("failed to load TLS credentials: %v", err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	}
+
+	s := grpc.NewServer(serverOpts...)
+
+	pb.RegisterBackendServiceServer(s, server.NewServer(qer, service.NewHash(), service.NewAuth(),
+		server.WithBlobStore(blobs, server.AttachmentLimits{
+			MaxSize:      c.GetAttachmentMaxSize(),
+			AllowedTypes: c.GetAttachmentAllowedTypes(),
+		}),
+		server.WithThumbnailGenerator(thumbnails),
+		server.WithSessionLifetime(func() time.Duration {
+			return store.Load().GetSessionLifetime()
+		}),
+		server.WithMetrics(serverMetrics),
+	))
+
+	// データベースに接続できない間はロードバランサーが振り分けないようNOT_SERVINGにする
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthServer)
+
+	healthServer.SetServingStatus(pb.BackendService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	if checker != nil {
+		checker.OnChange(func(healthy bool) {
+			status := healthpb.HealthCheckResponse_NOT_SERVING
+			if healthy {
+				status = healthpb.HealthCheckResponse_SERVING
+			}
+			healthServer.SetServingStatus("", status)
+			healthServer.SetServingStatus(pb.BackendService_ServiceDesc.ServiceName, status)
+		})
+		go checker.Run(ctx)
+	}
+
+	if c.GetReflection() {
+		reflection.Register(s)
+	}
+
+	errCh := make(chan error, len(listeners)+1)
+	for _, ln := range listeners {
+		go func(ln net.Listener) {
+			logger.Infof("listening server with %s", ln.Addr())
+			errCh <- s.Serve(ln)
+		}(ln)
+	}
+
+	var adminServer *http.Server
+	if c.GetAdminAddr() != "" {
+		adminServer = admin.NewServer(c.GetAdminAddr(), admin.NewHandler(adminDB))
+		go func() {
+			logger.Infof("listening admin server with %s", c.GetAdminAddr())
+			if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- xerrors.Errorf("admin server: %v", err)
+			}
+		}()
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(quit)
+
+	select {
+	case err := <-errCh:
+		s.Stop()
+		shutdownAdmin(adminServer)
+		thumbnails.Close()
+		return xerrors.Errorf("failed to serve: %v", err)
+	case <-quit:
+	}
+
+	// 停止中はロードバランサーから外れるよう先にヘルスチェックを止める
+	healthServer.Shutdown()
+	shutdownAdmin(adminServer)
+
+	logger.Infof("stopping gRPC server...")
+	s.GracefulStop()
+	logger.Infof("grpc server shutdown completed")
+
+	// 受け付け済みのサムネイル生成を終えてから終了する
+	thumbnails.Close()
+
+	return nil
+}
+
+// openDatabase はプライマリと設定されたレプリカに接続し、それらを使うQueryを返す。
+// 返した関数で接続を閉じる
+func openDatabase(ctx context.Context, c *config.Config) (*bun.DB, *database.Query, func(), error) {
+	secrets, err := secret.NewProvider(c)
+	if err != nil {
+		return nil, nil, nil, xerrors.Errorf("failed to initialize secret provider: %v", err)
+	}
+
+	conn, err := database.NewConnector(ctx, c, secrets)
+	if err != nil {
+		return nil, nil, nil, xerrors.Errorf("failed to get database credentials: %v", err)
+	}
+
+	db, err := database.NewDatabase(ctx, c, conn)
+	if err != nil {
+		return nil, nil, nil, xerrors.Errorf("failed to initialize database connection: %v", err)
+	}
+
+	// プライマリとレプリカのクエリを同じ指標に記録する
+	hook := database.NewQueryHook(database.WithSlowQueryThreshold(c.GetDBSlowQueryThreshold()))
+	if err := prometheus.Register(hook); err != nil {
+		db.Close()
+		return nil, nil, nil, xerrors.Errorf("failed to register query metrics: %v", err)
+	}
+	db.AddQueryHook(hook)
+
+	dbs := database.NewReplicas(c, conn)
+	for _, r := range dbs {
+		r.AddQueryHook(hook)
+	}
+
+	// レプリカの接続プールもプライマリと同じ認証情報を使うため、まとめて接続し直す
+	if c.GetSecretRefreshInterval() > 0 {
+		pools := []*sql.DB{db.DB}
+		for _, r := range dbs {
+			pools = append(pools, r.DB)
+		}
+		go database.WatchCredentials(ctx, pools, conn, c.GetSecretRefreshInterval())
+	}
+
+	if len(dbs) == 0 {
+		return db, database.NewQuery(db, database.WithLocation(c.GetDBLocation())), func() { db.Close() }, nil
+	}
+
+	replicas := database.NewReplicaSet(dbs, c.GetDBReplicaStickyWindow())
+	go replicas.Run(ctx, c.GetDBHealthInterval())
+
+	closeDB := func() {
+		replicas.Close()
+		db.Close()
+	}
+
+	return db, database.NewQuery(db, database.WithReplicas(replicas), database.WithLocation(c.GetDBLocation())), closeDB, nil
+}
+
+func applyLogLevel(c *config.Config) {
+	l, _ := logger.ParseLevel(c.GetLogLevel())
+	logger.SetLevel(l)
+}
+
+// listenUnix は前回の異常終了で残ったソケットファイルを削除してから待ち受ける
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Stat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, xerrors.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, xerrors.Errorf("failed to remove stale socket: %v", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to listen on unix socket: %v", err)
+	}
+
+	return ln, nil
+}
+
+func shutdownAdmin(s *http.Server) {
+	if s == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.Shutdown(ctx); err != nil {
+		logger.Errorf("failed to shutdown admin server: %v", err)
+	}
+}
@@ -0,0 +1,430 @@
+package collab
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/logger"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DefaultFlushInterval = 5 * time.Second
+
+	maxHistory   = 1000
+	eventBufSize = 64
+)
+
+var (
+	ErrRevisionOutOfRange = xerrors.New("collab: revision out of range")
+	ErrClientClosed       = xerrors.New("collab: client closed")
+)
+
+type EventType int
+
+const (
+	EventOperation EventType = iota + 1
+	EventAck
+	EventPresence
+)
+
+type Participant struct {
+	ID     string
+	UserID int64
+}
+
+// Event はクライアントへ配信する編集イベント
+type Event struct {
+	Type         EventType
+	Revision     int64
+	Operation    Operation
+	From         Participant
+	Participants []Participant
+}
+
+// Hub は記事ごとの編集セッションをメモリ上で管理する
+type Hub struct {
+	db            database.Querier
+	flushInterval time.Duration
+
+	// replaced はReplaceのたびに増える。読み込み中に置き換えられた記事は読み込み直す
+	replaced atomic.Uint64
+
+	mu       sync.Mutex
+	sessions map[int64]*session
+}
+
+func NewHub(db database.Querier, flushInterval time.Duration) *Hub {
+	return &Hub{
+		db:            db,
+		flushInterval: flushInterval,
+		sessions:      make(map[int64]*session),
+	}
+}
+
+type session struct {
+	hub       *Hub
+	articleID int64
+	userID    int64
+
+	mu       sync.Mutex
+	text     string
+	revision int64
+	history  []Operation
+	clients  map[*Client]struct{}
+	dirty    bool
+	closed   bool
+	done     chan struct{}
+
+	flushMu sync.Mutex
+	// flushed は閉じたセッションの本文を保存し終えると閉じられる
+	flushed chan struct{}
+}
+
+// Client は編集セッションに参加している1つの接続
+type Client struct {
+	Participant
+
+	session *session
+	events  chan Event
+	closed  bool
+}
+
+// Events は配信されるイベントのチャネルで、セッションから外れると閉じられる
+func (c *Client) Events() <-chan Event {
+	return c.events
+}
+
+// Join は記事の編集セッションに参加し、参加時点のリビジョンと本文を返す
+func (h *Hub) Join(ctx context.Context, articleID, userID int64) (*Client, int64, string, error) {
+	for {
+		s, err := h.session(ctx, articleID, userID)
+		if err != nil {
+			return nil, 0, "", err
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			flushed := s.flushed
+			s.mu.Unlock()
+
+			// 閉じている途中のセッションは本文を保存し終えてから作り直す
+			select {
+			case <-flushed:
+				continue
+			case <-ctx.Done():
+				return nil, 0, "", ctx.Err()
+			}
+		}
+
+		c := &Client{
+			Participant: Participant{ID: uuid.NewString(), UserID: userID},
+			session:     s,
+			events:      make(chan Event, eventBufSize),
+		}
+		s.clients[c] = struct{}{}
+		s.broadcastPresence()
+		revision, text := s.revision, s.text
+		s.mu.Unlock()
+
+		return c, revision, text, nil
+	}
+}
+
+// session は記事のセッションを返す。なければ記事を読み込んで作成する。
+// 読み込みの間は他の記事のセッションを待たせないようh.muを保持しない
+func (h *Hub) session(ctx context.Context, articleID, userID int64) (*session, error) {
+	for {
+		h.mu.Lock()
+		s, ok := h.sessions[articleID]
+		h.mu.Unlock()
+		if ok {
+			return s.owned(userID)
+		}
+
+		replaced := h.replaced.Load()
+		resp, err := h.db.GetArticle(ctx, database.GetArticleParams{
+			ArticleID: articleID,
+			UserID:    userID,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("failed to load article: %w", err)
+		}
+
+		if s, ok := h.create(articleID, userID, resp.Article.Text, replaced); ok {
+			return s.owned(userID)
+		}
+	}
+}
+
+// create は読み込んだ本文でセッションを作成する。読み込んでいる間に他の参加者が作成した場合はそちらを返す。
+// 本文がReplaceで置き換えられた可能性がある場合は作成せずにfalseを返す
+func (h *Hub) create(articleID, userID int64, text string, replaced uint64) (*session, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s, ok := h.sessions[articleID]; ok {
+		return s, true
+	}
+	if h.replaced.Load() != replaced {
+		return nil, false
+	}
+
+	s := &session{
+		hub:       h,
+		articleID: articleID,
+		userID:    userID,
+		text:      text,
+		clients:   make(map[*Client]struct{}),
+		done:      make(chan struct{}),
+		flushed:   make(chan struct{}),
+	}
+	h.sessions[articleID] = s
+
+	go s.flushLoop()
+
+	return s, true
+}
+
+// Replace は記事の本文がUpdateArticleなどでtextに更新されたときに呼ぶ。
+// 編集セッションがあれば置き換える操作として参加者に配信し、次の保存で更新前の本文に戻さないようにする
+func (h *Hub) Replace(articleID, userID int64, text string) {
+	h.replaced.Add(1)
+
+	h.mu.Lock()
+	s, ok := h.sessions[articleID]
+	h.mu.Unlock()
+	if !ok || s.userID != userID {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.text != text {
+		s.apply(diff(s.text, text), nil)
+	}
+}
+
+func (s *session) owned(userID int64) (*session, error) {
+	if s.userID != userID {
+		return nil, xerrors.Errorf("article not found: %w", sql.ErrNoRows)
+	}
+	return s, nil
+}
+
+// Submit はrevisionを基準にしたクライアントの操作を最新の文書に合わせて変換して適用する
+func (c *Client) Submit(revision int64, op Operation) error {
+	op, err := op.Normalize()
+	if err != nil {
+		return err
+	}
+
+	s := c.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c.closed {
+		return ErrClientClosed
+	}
+
+	base := s.revision - int64(len(s.history))
+	if revision < base || revision > s.revision {
+		return ErrRevisionOutOfRange
+	}
+
+	for _, concurrent := range s.history[revision-base:] {
+		if op, _, err = Transform(op, concurrent); err != nil {
+			return err
+		}
+	}
+
+	return s.apply(op, c)
+}
+
+// apply は最新の文書に合わせた操作を適用して参加者に配信する。呼び出し側でs.muを保持していること。
+// fromには操作を送ったクライアントを渡して確認応答を返す。記事の更新による置き換えの場合はnilを渡す
+func (s *session) apply(op Operation, from *Client) error {
+	text, err := Apply(s.text, op)
+	if err != nil {
+		return err
+	}
+
+	s.text = text
+	s.revision++
+	s.dirty = true
+	s.history = append(s.history, op)
+	if len(s.history) > maxHistory {
+		s.history = s.history[len(s.history)-maxHistory:]
+	}
+
+	sender := Participant{UserID: s.userID}
+	if from != nil {
+		sender = from.Participant
+	}
+
+	for other := range s.clients {
+		if other == from {
+			s.send(other, Event{Type: EventAck, Revision: s.revision})
+			continue
+		}
+		s.send(other, Event{
+			Type:      EventOperation,
+			Revision:  s.revision,
+			Operation: op,
+			From:      sender,
+		})
+	}
+
+	return nil
+}
+
+// Leave はセッションから抜ける。最後の参加者が抜けた場合は本文を保存してセッションを閉じる
+func (c *Client) Leave() {
+	s := c.session
+
+	s.mu.Lock()
+	if !c.closed {
+		s.remove(c)
+		s.broadcastPresence()
+	}
+	s.mu.Unlock()
+
+	s.closeIfIdle()
+}
+
+// closeIfIdle は参加者がいなければ本文を保存してセッションを閉じ、参加者がいた場合はfalseを返す。
+// 同じ記事のセッションが再作成される前に最新の本文を保存するため、保存し終えるまでsessionsに残し、参加しようとした接続はflushedを待つ。
+// 保存に失敗した場合は編集内容を失わないようセッションを開いたまま残し、flushLoopで保存し直してから閉じる
+func (s *session) closeIfIdle() bool {
+	h := s.hub
+
+	s.mu.Lock()
+	if len(s.clients) > 0 {
+		s.mu.Unlock()
+		return false
+	}
+	if s.closed {
+		s.mu.Unlock()
+		return true
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	// 保存している間にReplaceで置き換えられた本文も保存する
+	for {
+		if err := s.flush(); err != nil {
+			s.mu.Lock()
+			s.closed = false
+			flushed := s.flushed
+			s.flushed = make(chan struct{})
+			s.mu.Unlock()
+
+			// 待っていた接続はこのセッションに参加する
+			close(flushed)
+			return true
+		}
+
+		s.mu.Lock()
+		dirty := s.dirty
+		s.mu.Unlock()
+		if !dirty {
+			break
+		}
+	}
+
+	close(s.done)
+
+	h.mu.Lock()
+	if h.sessions[s.articleID] == s {
+		delete(h.sessions, s.articleID)
+	}
+	h.mu.Unlock()
+
+	s.mu.Lock()
+	close(s.flushed)
+	s.mu.Unlock()
+
+	return true
+}
+
+// send は呼び出し側でs.muを保持していること。
+// 受信が追いつかないクライアントはセッションから外す
+func (s *session) send(c *Client, e Event) {
+	select {
+	case c.events <- e:
+	default:
+		logger.Warnf("collab: dropping slow participant %s on article %d", c.ID, s.articleID)
+		s.remove(c)
+	}
+}
+
+func (s *session) remove(c *Client) {
+	delete(s.clients, c)
+	c.closed = true
+	close(c.events)
+}
+
+func (s *session) broadcastPresence() {
+	participants := make([]Participant, 0, len(s.clients))
+	for c := range s.clients {
+		participants = append(participants, c.Participant)
+	}
+
+	for c := range s.clients {
+		s.send(c, Event{Type: EventPresence, Revision: s.revision, Participants: participants})
+	}
+}
+
+func (s *session) flushLoop() {
+	ticker := time.NewTicker(s.hub.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !s.closeIfIdle() {
+				s.flush()
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *session) flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	// タイトルや説明は編集中にUpdateArticleで変更されることがあるため本文だけを保存する
+	params := database.UpdateArticleTextParams{
+		ArticleID: s.articleID,
+		UserID:    s.userID,
+		Text:      s.text,
+	}
+	s.dirty = false
+	s.mu.Unlock()
+
+	if err := s.hub.db.UpdateArticleText(context.Background(), params); err != nil {
+		logger.Errorf("collab: failed to persist article %d: %v", s.articleID, err)
+
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
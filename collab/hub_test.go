@@ -0,0 +1,404 @@
+package collab
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/database/memory"
+	mock_database "sample-grpc-server/database/mock"
+	"sample-grpc-server/database/model"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestHub_Join(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("記事が存在しない", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().GetArticle(gomock.Any(), gomock.Any()).Return(nil, sql.ErrNoRows)
+
+		h := NewHub(db, time.Hour)
+
+		_, _, _, err := h.Join(context.Background(), 1, 1)
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expect: %v, Got: %v", sql.ErrNoRows, err)
+		}
+	})
+
+	t.Run("他のユーザーのセッション", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().GetArticle(gomock.Any(), gomock.Any()).Return(newArticleResult("abc"), nil)
+		db.EXPECT().UpdateArticleText(gomock.Any(), gomock.Any()).Times(0)
+
+		h := NewHub(db, time.Hour)
+
+		c, _, _, err := h.Join(context.Background(), 1, 1)
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		defer c.Leave()
+
+		_, _, _, err = h.Join(context.Background(), 1, 2)
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expect: %v, Got: %v", sql.ErrNoRows, err)
+		}
+	})
+}
+
+func TestClient_Submit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := mock_database.NewMockQuerier(ctrl)
+	db.EXPECT().GetArticle(gomock.Any(), gomock.Any()).Return(newArticleResult("abc"), nil)
+	db.EXPECT().UpdateArticleText(gomock.Any(), database.UpdateArticleTextParams{
+		ArticleID: 1,
+		UserID:    1,
+		Text:      "xabcy",
+	}).Return(nil)
+
+	h := NewHub(db, time.Hour)
+	ctx := context.Background()
+
+	a, rev, text, err := h.Join(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if rev != 0 || text != "abc" {
+		t.Fatalf("unexpected snapshot: %d %q", rev, text)
+	}
+
+	b, _, _, err := h.Join(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	expectPresence(t, a, 1)
+	expectPresence(t, a, 2)
+	expectPresence(t, b, 2)
+
+	// a, bともにリビジョン0を基準にした並行な操作を送る
+	if err := a.Submit(0, Operation{{Insert: "x"}, {Retain: 3}}); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if err := b.Submit(0, Operation{{Retain: 3}, {Insert: "y"}}); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	if e := <-a.Events(); e.Type != EventAck || e.Revision != 1 {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if e := <-b.Events(); e.Type != EventOperation || e.From.ID != a.ID {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if e := <-a.Events(); e.Type != EventOperation || e.From.ID != b.ID || e.Operation.BaseLen() != 4 {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if e := <-b.Events(); e.Type != EventAck || e.Revision != 2 {
+		t.Errorf("unexpected event: %+v", e)
+	}
+
+	if err := a.Submit(5, Operation{{Retain: 1}}); !errors.Is(err, ErrRevisionOutOfRange) {
+		t.Errorf("Expect: %v, Got: %v", ErrRevisionOutOfRange, err)
+	}
+
+	b.Leave()
+	expectPresence(t, a, 1)
+
+	// 最後の参加者が抜けると本文が保存される
+	a.Leave()
+
+	if _, ok := <-a.Events(); ok {
+		t.Error("events should be closed")
+	}
+}
+
+func TestHub_keepsConcurrentUpdate(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewQuerier()
+
+	user, err := db.SignUp(ctx, database.SignUpParams{Email: "test@example.com", Password: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	article, err := db.CreateArticle(ctx, database.CreateArticleParams{UserID: user.UserID, Title: "title", Text: "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHub(db, time.Hour)
+	c, _, _, err := h.Join(ctx, article.ArticleID, user.UserID)
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	expectPresence(t, c, 1)
+
+	// 編集中にタイトルと説明が変更される
+	err = db.UpdateArticle(ctx, database.UpdateArticleParams{
+		ArticleID:   article.ArticleID,
+		UserID:      user.UserID,
+		Title:       "updated",
+		Description: sql.NullString{String: "description", Valid: true},
+		Text:        "abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Submit(0, Operation{{Retain: 3}, {Insert: "d"}}); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	c.Leave()
+
+	got, err := db.GetArticle(ctx, database.GetArticleParams{ArticleID: article.ArticleID, UserID: user.UserID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Article.Text != "abcd" || got.Article.Title != "updated" || got.Article.Description.String != "description" {
+		t.Errorf("only text should be persisted: %+v", got.Article)
+	}
+}
+
+func TestHub_Replace(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewQuerier()
+
+	user, err := db.SignUp(ctx, database.SignUpParams{Email: "test@example.com", Password: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	article, err := db.CreateArticle(ctx, database.CreateArticleParams{UserID: user.UserID, Title: "title", Text: "hello world"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHub(db, time.Hour)
+	c, _, _, err := h.Join(ctx, article.ArticleID, user.UserID)
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	expectPresence(t, c, 1)
+
+	// 編集中にUpdateArticleで本文が更新される
+	err = db.UpdateArticle(ctx, database.UpdateArticleParams{
+		ArticleID: article.ArticleID,
+		UserID:    user.UserID,
+		Title:     "title",
+		Text:      "hello there world",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Replace(article.ArticleID, user.UserID, "hello there world")
+
+	if e := <-c.Events(); e.Type != EventOperation || e.Revision != 1 {
+		t.Errorf("unexpected event: %+v", e)
+	}
+
+	// 置き換えを受け取る前の操作も置き換えた本文に合わせて適用される
+	if err := c.Submit(0, Operation{{Retain: 11}, {Insert: "!"}}); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	c.Leave()
+
+	got, err := db.GetArticle(ctx, database.GetArticleParams{ArticleID: article.ArticleID, UserID: user.UserID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Article.Text != "hello there world!" {
+		t.Errorf("Expect: %q, Got: %q", "hello there world!", got.Article.Text)
+	}
+}
+
+func TestHub_Replace_whileLoading(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := mock_database.NewMockQuerier(ctrl)
+	var h *Hub
+	gomock.InOrder(
+		// 読み込んでいる間に本文が更新される
+		db.EXPECT().GetArticle(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(context.Context, database.GetArticleParams) (*database.GetArticleResult, error) {
+				h.Replace(1, 1, "updated")
+				return newArticleResult("abc"), nil
+			}),
+		db.EXPECT().GetArticle(gomock.Any(), gomock.Any()).Return(newArticleResult("updated"), nil),
+	)
+
+	h = NewHub(db, time.Hour)
+
+	c, _, text, err := h.Join(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	defer c.Leave()
+
+	if text != "updated" {
+		t.Errorf("Expect: %q, Got: %q", "updated", text)
+	}
+}
+
+func TestClient_Leave_flushFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	params := database.UpdateArticleTextParams{ArticleID: 1, UserID: 1, Text: "abcd"}
+
+	t.Run("保存できなかった編集内容で参加し直せる", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().GetArticle(gomock.Any(), gomock.Any()).Return(newArticleResult("abc"), nil).Times(1)
+		gomock.InOrder(
+			db.EXPECT().UpdateArticleText(gomock.Any(), params).Return(errors.New("connection refused")),
+			db.EXPECT().UpdateArticleText(gomock.Any(), params).Return(nil),
+		)
+
+		h := NewHub(db, time.Hour)
+		ctx := context.Background()
+
+		c, _, _, err := h.Join(ctx, 1, 1)
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if err := c.Submit(0, Operation{{Retain: 3}, {Insert: "d"}}); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		c.Leave()
+
+		c, rev, text, err := h.Join(ctx, 1, 1)
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if rev != 1 || text != "abcd" {
+			t.Errorf("unexpected snapshot: %d %q", rev, text)
+		}
+		c.Leave()
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if n := len(h.sessions); n != 0 {
+			t.Errorf("Expect: %v, Got: %v", 0, n)
+		}
+	})
+
+	t.Run("参加者がいなくても保存し直す", func(t *testing.T) {
+		saved := make(chan struct{})
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().GetArticle(gomock.Any(), gomock.Any()).Return(newArticleResult("abc"), nil)
+		gomock.InOrder(
+			db.EXPECT().UpdateArticleText(gomock.Any(), params).Return(errors.New("connection refused")),
+			db.EXPECT().UpdateArticleText(gomock.Any(), params).
+				DoAndReturn(func(context.Context, database.UpdateArticleTextParams) error {
+					close(saved)
+					return nil
+				}),
+		)
+
+		h := NewHub(db, 10*time.Millisecond)
+
+		c, _, _, err := h.Join(context.Background(), 1, 1)
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if err := c.Submit(0, Operation{{Retain: 3}, {Insert: "d"}}); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		c.Leave()
+
+		select {
+		case <-saved:
+		case <-time.After(time.Second):
+			t.Fatal("unsaved text should be flushed again")
+		}
+
+		// 保存し終えるとセッションを閉じる
+		for i := 0; i < 100; i++ {
+			h.mu.Lock()
+			n := len(h.sessions)
+			h.mu.Unlock()
+			if n == 0 {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Error("session should be closed after flushing")
+	})
+}
+
+func TestHub_Join_doesNotBlockOtherArticles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	block := make(chan struct{})
+	db := mock_database.NewMockQuerier(ctrl)
+	db.EXPECT().GetArticle(gomock.Any(), database.GetArticleParams{ArticleID: 1, UserID: 1}).
+		DoAndReturn(func(context.Context, database.GetArticleParams) (*database.GetArticleResult, error) {
+			<-block
+			return newArticleResult("abc"), nil
+		})
+	db.EXPECT().GetArticle(gomock.Any(), database.GetArticleParams{ArticleID: 2, UserID: 1}).Return(newArticleResult("def"), nil)
+
+	h := NewHub(db, time.Hour)
+
+	joined := make(chan error)
+	go func() {
+		c, _, _, err := h.Join(context.Background(), 1, 1)
+		if err == nil {
+			c.Leave()
+		}
+		joined <- err
+	}()
+
+	// 記事1の読み込みを待っている間も記事2には参加できる
+	done := make(chan error)
+	go func() {
+		c, _, _, err := h.Join(context.Background(), 2, 1)
+		if err == nil {
+			c.Leave()
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("err should be nil: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("join should not wait for other articles")
+	}
+
+	close(block)
+	if err := <-joined; err != nil {
+		t.Errorf("err should be nil: %v", err)
+	}
+}
+
+func expectPresence(t *testing.T, c *Client, n int) {
+	t.Helper()
+
+	e := <-c.Events()
+	if e.Type != EventPresence {
+		t.Fatalf("Expect: %v, Got: %v", EventPresence, e.Type)
+	}
+	if len(e.Participants) != n {
+		t.Errorf("Expect: %v, Got: %v", n, len(e.Participants))
+	}
+}
+
+func newArticleResult(text string) *database.GetArticleResult {
+	return &database.GetArticleResult{
+		Article: model.Article{
+			ID:     1,
+			UserID: 1,
+			Title:  "title",
+			Text:   text,
+		},
+	}
+}
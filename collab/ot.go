@@ -0,0 +1,239 @@
+package collab
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/xerrors"
+)
+
+var (
+	ErrInvalidOperation = xerrors.New("collab: invalid operation")
+	ErrLengthMismatch   = xerrors.New("collab: operation does not match document length")
+)
+
+// Component は操作の1要素で、Retain, Insert, Deleteのいずれか1つだけを持つ
+type Component struct {
+	Retain int
+	Insert string
+	Delete int
+}
+
+// Operation は文書全体をルーン単位で走査するテキスト操作
+type Operation []Component
+
+func (op Operation) retain(n int) Operation {
+	if n <= 0 {
+		return op
+	}
+	if l := len(op); l > 0 && op[l-1].Retain > 0 {
+		op[l-1].Retain += n
+		return op
+	}
+	return append(op, Component{Retain: n})
+}
+
+func (op Operation) insert(s string) Operation {
+	if s == "" {
+		return op
+	}
+
+	l := len(op)
+	if l > 0 && op[l-1].Insert != "" {
+		op[l-1].Insert += s
+		return op
+	}
+
+	// 削除の直後に挿入する場合は挿入を先に並べて正規化する
+	if l > 0 && op[l-1].Delete > 0 {
+		if l > 1 && op[l-2].Insert != "" {
+			op[l-2].Insert += s
+			return op
+		}
+		op = append(op, op[l-1])
+		op[l-1] = Component{Insert: s}
+		return op
+	}
+
+	return append(op, Component{Insert: s})
+}
+
+func (op Operation) delete(n int) Operation {
+	if n <= 0 {
+		return op
+	}
+	if l := len(op); l > 0 && op[l-1].Delete > 0 {
+		op[l-1].Delete += n
+		return op
+	}
+	return append(op, Component{Delete: n})
+}
+
+// Normalize は各要素を検証し、隣接する同種の要素を結合した操作を返す
+func (op Operation) Normalize() (Operation, error) {
+	var out Operation
+	for _, c := range op {
+		kinds := 0
+		if c.Retain != 0 {
+			kinds++
+		}
+		if c.Insert != "" {
+			kinds++
+		}
+		if c.Delete != 0 {
+			kinds++
+		}
+		if kinds != 1 || c.Retain < 0 || c.Delete < 0 {
+			return nil, ErrInvalidOperation
+		}
+
+		switch {
+		case c.Retain > 0:
+			out = out.retain(c.Retain)
+		case c.Insert != "":
+			out = out.insert(c.Insert)
+		default:
+			out = out.delete(c.Delete)
+		}
+	}
+
+	return out, nil
+}
+
+// BaseLen は操作を適用できる文書のルーン数
+func (op Operation) BaseLen() int {
+	n := 0
+	for _, c := range op {
+		n += c.Retain + c.Delete
+	}
+	return n
+}
+
+// TargetLen は操作を適用した後の文書のルーン数
+func (op Operation) TargetLen() int {
+	n := 0
+	for _, c := range op {
+		n += c.Retain + utf8.RuneCountInString(c.Insert)
+	}
+	return n
+}
+
+// Apply は文書に操作を適用する
+func Apply(doc string, op Operation) (string, error) {
+	runes := []rune(doc)
+	if op.BaseLen() != len(runes) {
+		return "", ErrLengthMismatch
+	}
+
+	out := make([]rune, 0, op.TargetLen())
+	pos := 0
+	for _, c := range op {
+		switch {
+		case c.Retain > 0:
+			out = append(out, runes[pos:pos+c.Retain]...)
+			pos += c.Retain
+		case c.Insert != "":
+			out = append(out, []rune(c.Insert)...)
+		default:
+			pos += c.Delete
+		}
+	}
+
+	return string(out), nil
+}
+
+// Transform は同じ文書に対する並行した操作a, bを変換し、
+// apply(apply(doc, a), b') == apply(apply(doc, b), a') となる組(a', b')を返す。
+// 同じ位置への挿入はaを先に並べる。
+func Transform(a, b Operation) (Operation, Operation, error) {
+	if a.BaseLen() != b.BaseLen() {
+		return nil, nil, ErrLengthMismatch
+	}
+
+	var ap, bp Operation
+	a, b = append(Operation(nil), a...), append(Operation(nil), b...)
+	i, j := 0, 0
+
+	for i < len(a) || j < len(b) {
+		if i < len(a) && a[i].Insert != "" {
+			ap = ap.insert(a[i].Insert)
+			bp = bp.retain(utf8.RuneCountInString(a[i].Insert))
+			i++
+			continue
+		}
+		if j < len(b) && b[j].Insert != "" {
+			ap = ap.retain(utf8.RuneCountInString(b[j].Insert))
+			bp = bp.insert(b[j].Insert)
+			j++
+			continue
+		}
+		if i >= len(a) || j >= len(b) {
+			return nil, nil, ErrInvalidOperation
+		}
+
+		ca, cb := &a[i], &b[j]
+		n := length(*ca)
+		if m := length(*cb); m < n {
+			n = m
+		}
+
+		switch {
+		case ca.Retain > 0 && cb.Retain > 0:
+			ap = ap.retain(n)
+			bp = bp.retain(n)
+		case ca.Delete > 0 && cb.Delete > 0:
+			// 両方が同じ範囲を削除しているので何もしない
+		case ca.Delete > 0:
+			ap = ap.delete(n)
+		default:
+			bp = bp.delete(n)
+		}
+
+		if consume(ca, n) {
+			i++
+		}
+		if consume(cb, n) {
+			j++
+		}
+	}
+
+	return ap, bp, nil
+}
+
+func length(c Component) int {
+	if c.Retain > 0 {
+		return c.Retain
+	}
+	return c.Delete
+}
+
+// consume は要素をn文字分消費し、使い切った場合にtrueを返す
+func consume(c *Component, n int) bool {
+	if c.Retain > 0 {
+		c.Retain -= n
+		return c.Retain == 0
+	}
+	c.Delete -= n
+	return c.Delete == 0
+}
+
+// diff はoldをnewにする操作を返す。前後の一致する部分は残し、異なる部分だけを置き換える
+func diff(old, new string) Operation {
+	a, b := []rune(old), []rune(new)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var op Operation
+	op = op.retain(prefix)
+	op = op.insert(string(b[prefix : len(b)-suffix]))
+	op = op.delete(len(a) - prefix - suffix)
+	op = op.retain(suffix)
+
+	return op
+}
@@ -0,0 +1,196 @@
+package collab
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		op      Operation
+		want    string
+		wantErr error
+	}{
+		{
+			name: "挿入",
+			doc:  "hello",
+			op:   Operation{{Retain: 5}, {Insert: " world"}},
+			want: "hello world",
+		},
+		{
+			name: "削除",
+			doc:  "hello world",
+			op:   Operation{{Retain: 5}, {Delete: 6}},
+			want: "hello",
+		},
+		{
+			name: "マルチバイト文字",
+			doc:  "こんにちは",
+			op:   Operation{{Delete: 3}, {Insert: "さような"}, {Retain: 2}},
+			want: "さようなちは",
+		},
+		{
+			name:    "長さ不一致",
+			doc:     "hello",
+			op:      Operation{{Retain: 4}},
+			wantErr: ErrLengthMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(tt.doc, tt.op)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expect: %v, Got: %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expect: %q, Got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOperation_Normalize(t *testing.T) {
+	t.Run("隣接する要素の結合", func(t *testing.T) {
+		op := Operation{{Retain: 1}, {Retain: 2}, {Delete: 1}, {Insert: "a"}, {Insert: "b"}}
+
+		got, err := op.Normalize()
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		expect := Operation{{Retain: 3}, {Insert: "ab"}, {Delete: 1}}
+		if !reflect.DeepEqual(expect, got) {
+			t.Errorf("Expect: %v, Got: %v", expect, got)
+		}
+	})
+
+	t.Run("不正な要素", func(t *testing.T) {
+		for _, op := range []Operation{
+			{{}},
+			{{Retain: 1, Delete: 1}},
+			{{Retain: -1}},
+		} {
+			if _, err := op.Normalize(); !errors.Is(err, ErrInvalidOperation) {
+				t.Errorf("Expect: %v, Got: %v", ErrInvalidOperation, err)
+			}
+		}
+	})
+}
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		a    Operation
+		b    Operation
+		want string
+	}{
+		{
+			name: "異なる位置への挿入",
+			doc:  "abc",
+			a:    Operation{{Insert: "x"}, {Retain: 3}},
+			b:    Operation{{Retain: 3}, {Insert: "y"}},
+			want: "xabcy",
+		},
+		{
+			name: "同じ位置への挿入",
+			doc:  "abc",
+			a:    Operation{{Retain: 1}, {Insert: "x"}, {Retain: 2}},
+			b:    Operation{{Retain: 1}, {Insert: "y"}, {Retain: 2}},
+			want: "axybc",
+		},
+		{
+			name: "重なる削除",
+			doc:  "abcdef",
+			a:    Operation{{Retain: 1}, {Delete: 3}, {Retain: 2}},
+			b:    Operation{{Retain: 2}, {Delete: 3}, {Retain: 1}},
+			want: "af",
+		},
+		{
+			name: "削除範囲への挿入",
+			doc:  "abcdef",
+			a:    Operation{{Retain: 1}, {Delete: 4}, {Retain: 1}},
+			b:    Operation{{Retain: 3}, {Insert: "xyz"}, {Retain: 3}},
+			want: "axyzf",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap, bp, err := Transform(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("err should be nil: %v", err)
+			}
+
+			left, err := applyAll(tt.doc, tt.a, bp)
+			if err != nil {
+				t.Fatalf("err should be nil: %v", err)
+			}
+
+			right, err := applyAll(tt.doc, tt.b, ap)
+			if err != nil {
+				t.Fatalf("err should be nil: %v", err)
+			}
+
+			if left != right {
+				t.Errorf("not converged: %q != %q", left, right)
+			}
+
+			if left != tt.want {
+				t.Errorf("Expect: %q, Got: %q", tt.want, left)
+			}
+		})
+	}
+
+	t.Run("長さ不一致", func(t *testing.T) {
+		_, _, err := Transform(Operation{{Retain: 1}}, Operation{{Retain: 2}})
+		if !errors.Is(err, ErrLengthMismatch) {
+			t.Errorf("Expect: %v, Got: %v", ErrLengthMismatch, err)
+		}
+	})
+}
+
+func Test_diff(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want Operation
+	}{
+		{name: "同じ", old: "abc", new: "abc", want: Operation{{Retain: 3}}},
+		{name: "途中を置き換える", old: "hello world", new: "hello there world", want: Operation{{Retain: 6}, {Insert: "there "}, {Retain: 5}}},
+		{name: "途中を削除する", old: "abcdef", new: "abef", want: Operation{{Retain: 2}, {Delete: 2}, {Retain: 2}}},
+		{name: "全体を置き換える", old: "abc", new: "xyz", want: Operation{{Insert: "xyz"}, {Delete: 3}}},
+		{name: "空にする", old: "abc", new: "", want: Operation{{Delete: 3}}},
+		{name: "マルチバイト文字", old: "こんにちは", new: "こんばんは", want: Operation{{Retain: 2}, {Insert: "ばん"}, {Delete: 2}, {Retain: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := diff(tt.old, tt.new)
+			if !reflect.DeepEqual(op, tt.want) {
+				t.Errorf("Expect: %v, Got: %v", tt.want, op)
+			}
+
+			got, err := Apply(tt.old, op)
+			if err != nil {
+				t.Fatalf("err should be nil: %v", err)
+			}
+			if got != tt.new {
+				t.Errorf("Expect: %q, Got: %q", tt.new, got)
+			}
+		})
+	}
+}
+
+func applyAll(doc string, ops ...Operation) (string, error) {
+	var err error
+	for _, op := range ops {
+		if doc, err = Apply(doc, op); err != nil {
+			return "", err
+		}
+	}
+	return doc, nil
+}
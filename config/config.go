@@ -1,24 +1,169 @@
 package config
 
-import "os"
+import (
+	"strings"
+	"time"
+
+	// 本番イメージにはタイムゾーンのデータベースがないため埋め込む
+	_ "time/tzdata"
+)
 
 const (
-	defaultPort = "8080"
-	defaultEnv  = "development"
+	defaultPort                   = "8080"
+	defaultEnv                    = "development"
+	defaultAttachmentDir          = "attachments"
+	defaultAttachmentMaxSize      = 10 << 20
+	defaultAttachmentAllowedTypes = "image/jpeg,image/png,image/gif,application/pdf,text/plain"
+	defaultThumbnailWorkers       = 2
+	defaultLogLevel               = "info"
+	defaultRateLimitBurst         = 20
+	defaultSessionLifetime        = 24 * time.Hour
+	defaultDBMaxOpenConns         = 25
+	defaultDBMaxIdleConns         = 10
+	defaultDBConnMaxLifetime      = 30 * time.Minute
+	defaultDBConnMaxIdleTime      = 5 * time.Minute
+	defaultDBHealthInterval       = 10 * time.Second
+	defaultDBConnectTimeout       = 30 * time.Second
+	defaultDBReplicaStickyWindow  = 5 * time.Second
+	defaultDBSlowQueryThreshold   = 200 * time.Millisecond
+	defaultCacheSessionTTL        = 30 * time.Second
+	defaultCacheArticleTTL        = time.Minute
+	defaultSecretProvider         = "env"
+	defaultSecretDir              = "/run/secrets"
 )
 
-var Cfg = &Config{
-	port: defaultPort,
-	env:  defaultEnv,
-}
+// storageに指定できるデータの保存先
+const (
+	StorageDatabase = "database"
+	StorageMemory   = "memory"
+)
+
+// db.driverに指定できるデータベースの種類
+const (
+	DBDriverMySQL    = "mysql"
+	DBDriverPostgres = "postgres"
+	// DBDriverSQLite はdb.nameのファイルに保存する。サーバーを用意せずに動かせる
+	DBDriverSQLite = "sqlite"
+)
 
 type Config struct {
 	port       string
+	listenAddr string
+	unixSocket string
+	adminAddr  string
 	env        string
+	storage    string
+	dbDriver   string
 	dbUser     string
 	dbPassword string
 	dbName     string
 	dbAddr     string
+	dbLocation *time.Location
+
+	dbMaxOpenConns    int
+	dbMaxIdleConns    int
+	dbConnMaxLifetime time.Duration
+	dbConnMaxIdleTime time.Duration
+	dbHealthInterval  time.Duration
+	dbConnectTimeout  time.Duration
+
+	dbSlowQueryThreshold time.Duration
+
+	dbReplicas            []string
+	dbReplicaStickyWindow time.Duration
+
+	cacheSize       int
+	cacheSessionTTL time.Duration
+	cacheArticleTTL time.Duration
+
+	tlsCertFile string
+	tlsKeyFile  string
+	reflection  bool
+
+	attachmentDir          string
+	attachmentMaxSize      int64
+	attachmentAllowedTypes []string
+
+	thumbnailSizes   []int
+	thumbnailWorkers int
+
+	secretProvider        string
+	secretDir             string
+	secretFile            string
+	secretKeyFile         string
+	secretRefreshInterval time.Duration
+
+	// 以下は再起動せずに再読み込みできる
+	logLevel        string
+	rateLimitRPS    float64
+	rateLimitBurst  int
+	sessionLifetime time.Duration
+}
+
+// Default は設定ファイル・環境変数・フラグがいずれも指定されていない場合の設定を返す
+func Default() *Config {
+	return &Config{
+		port:                   defaultPort,
+		env:                    defaultEnv,
+		storage:                StorageDatabase,
+		dbDriver:               DBDriverMySQL,
+		dbLocation:             time.UTC,
+		dbMaxOpenConns:         defaultDBMaxOpenConns,
+		dbMaxIdleConns:         defaultDBMaxIdleConns,
+		dbConnMaxLifetime:      defaultDBConnMaxLifetime,
+		dbConnMaxIdleTime:      defaultDBConnMaxIdleTime,
+		dbHealthInterval:       defaultDBHealthInterval,
+		dbConnectTimeout:       defaultDBConnectTimeout,
+		dbSlowQueryThreshold:   defaultDBSlowQueryThreshold,
+		dbReplicaStickyWindow:  defaultDBReplicaStickyWindow,
+		cacheSessionTTL:        defaultCacheSessionTTL,
+		cacheArticleTTL:        defaultCacheArticleTTL,
+		reflection:             true,
+		attachmentDir:          defaultAttachmentDir,
+		attachmentMaxSize:      defaultAttachmentMaxSize,
+		attachmentAllowedTypes: strings.Split(defaultAttachmentAllowedTypes, ","),
+		thumbnailSizes:         []int{128, 512},
+		thumbnailWorkers:       defaultThumbnailWorkers,
+		secretProvider:         defaultSecretProvider,
+		secretDir:              defaultSecretDir,
+		logLevel:               defaultLogLevel,
+		rateLimitBurst:         defaultRateLimitBurst,
+		sessionLifetime:        defaultSessionLifetime,
+	}
+}
+
+func (c *Config) GetPort() string {
+	return c.port
+}
+
+// GetListenAddr はlisten_addrが指定されていればそれを、なければ全インターフェースのportを返す
+func (c *Config) GetListenAddr() string {
+	if c.listenAddr != "" {
+		return c.listenAddr
+	}
+	return ":" + c.port
+}
+
+func (c *Config) GetUnixSocket() string {
+	return c.unixSocket
+}
+
+func (c *Config) GetAdminAddr() string {
+	return c.adminAddr
+}
+
+func (c *Config) GetEnv() string {
+	return c.env
+}
+
+// GetStorage はデータの保存先(database, memory)を返す。memoryの場合はデータベースに接続しない
+func (c *Config) GetStorage() string {
+	return c.storage
+}
+
+// GetDBDriver は接続するデータベースの種類(mysql, postgres, sqlite)を返す
+func (c *Config) GetDBDriver() string {
+	return c.dbDriver
 }
 
 func (c *Config) GetDBUser() string {
@@ -37,26 +182,132 @@ func (c *Config) GetDBAddr() string {
 	return c.dbAddr
 }
 
-func LoadConfig() {
-	// PORTを読み込む
-	if port := os.Getenv("PORT"); port != "" {
-		Cfg.port = port
-	}
+// GetDBLocation はデータベースから取得した日時を変換するタイムゾーンを返す
+func (c *Config) GetDBLocation() *time.Location {
+	return c.dbLocation
+}
 
-	// 環境名を読み込む
-	if env := os.Getenv("ENV"); env != "" {
-		Cfg.env = env
-	}
+// GetDBMaxOpenConns は接続プールの最大接続数を返す。0の場合は制限しない
+func (c *Config) GetDBMaxOpenConns() int {
+	return c.dbMaxOpenConns
+}
+
+func (c *Config) GetDBMaxIdleConns() int {
+	return c.dbMaxIdleConns
+}
+
+func (c *Config) GetDBConnMaxLifetime() time.Duration {
+	return c.dbConnMaxLifetime
+}
+
+func (c *Config) GetDBConnMaxIdleTime() time.Duration {
+	return c.dbConnMaxIdleTime
+}
 
-	// ユーザー名を読み込む
-	Cfg.dbUser = os.Getenv("DB_USER")
+func (c *Config) GetDBHealthInterval() time.Duration {
+	return c.dbHealthInterval
+}
+
+// GetDBConnectTimeout は起動時にデータベースへの接続を再試行し続ける時間を返す
+func (c *Config) GetDBConnectTimeout() time.Duration {
+	return c.dbConnectTimeout
+}
+
+// GetDBSlowQueryThreshold はログに出すクエリの実行時間の下限を返す。0の場合は出さない
+func (c *Config) GetDBSlowQueryThreshold() time.Duration {
+	return c.dbSlowQueryThreshold
+}
+
+// GetDBReplicas は読み込みに使うレプリカのアドレスを返す。ユーザーやパスワードはプライマリと同じものを使う
+func (c *Config) GetDBReplicas() []string {
+	return c.dbReplicas
+}
+
+// GetDBReplicaStickyWindow は書き込んだユーザーの読み込みをプライマリに送り続ける時間を返す
+func (c *Config) GetDBReplicaStickyWindow() time.Duration {
+	return c.dbReplicaStickyWindow
+}
 
-	// パスワードを読み込む
-	Cfg.dbPassword = os.Getenv("DB_PASSWORD")
+// GetCacheSize はクエリの結果をメモリに保存する件数の上限を返す。0の場合は保存しない
+func (c *Config) GetCacheSize() int {
+	return c.cacheSize
+}
+
+func (c *Config) GetCacheSessionTTL() time.Duration {
+	return c.cacheSessionTTL
+}
 
-	// データベース名を読み込む
-	Cfg.dbName = os.Getenv("DB_NAME")
+func (c *Config) GetCacheArticleTTL() time.Duration {
+	return c.cacheArticleTTL
+}
+
+func (c *Config) GetTLSCertFile() string {
+	return c.tlsCertFile
+}
+
+func (c *Config) GetTLSKeyFile() string {
+	return c.tlsKeyFile
+}
+
+func (c *Config) GetReflection() bool {
+	return c.reflection
+}
+
+func (c *Config) GetAttachmentDir() string {
+	return c.attachmentDir
+}
+
+func (c *Config) GetAttachmentMaxSize() int64 {
+	return c.attachmentMaxSize
+}
+
+func (c *Config) GetAttachmentAllowedTypes() []string {
+	return c.attachmentAllowedTypes
+}
+
+func (c *Config) GetThumbnailSizes() []int {
+	return c.thumbnailSizes
+}
+
+func (c *Config) GetThumbnailWorkers() int {
+	return c.thumbnailWorkers
+}
+
+// GetSecretProvider はデータベースの認証情報を取得する方法(env, file, encrypted)を返す
+func (c *Config) GetSecretProvider() string {
+	return c.secretProvider
+}
+
+func (c *Config) GetSecretDir() string {
+	return c.secretDir
+}
+
+func (c *Config) GetSecretFile() string {
+	return c.secretFile
+}
+
+func (c *Config) GetSecretKeyFile() string {
+	return c.secretKeyFile
+}
+
+// GetSecretRefreshInterval は認証情報を取得し直す間隔を返す。0の場合は起動時にだけ取得する
+func (c *Config) GetSecretRefreshInterval() time.Duration {
+	return c.secretRefreshInterval
+}
+
+func (c *Config) GetLogLevel() string {
+	return c.logLevel
+}
+
+// GetRateLimitRPS は接続元ごとの1秒あたりのリクエスト数の上限を返す。0の場合は制限しない
+func (c *Config) GetRateLimitRPS() float64 {
+	return c.rateLimitRPS
+}
+
+func (c *Config) GetRateLimitBurst() int {
+	return c.rateLimitBurst
+}
 
-	// データベースホスト名を読み込む
-	Cfg.dbAddr = os.Getenv("DB_ADDR")
+func (c *Config) GetSessionLifetime() time.Duration {
+	return c.sessionLifetime
 }
@@ -0,0 +1,602 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+)
+
+// FileFlag は設定ファイルのパスを指定するフラグ名。環境変数CONFIG_FILEでも指定できる
+const FileFlag = "config"
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindBool
+	kindInt
+)
+
+// field は設定項目と、その値を指定できる設定ファイルのキー・環境変数・フラグの対応
+type field struct {
+	key   string
+	env   string
+	flag  string
+	kind  kind
+	usage string
+	get   func(c *Config) string
+	set   func(c *Config, v string) error
+
+	// reloadable な項目は起動中に再読み込みした値が反映される
+	reloadable bool
+	// secret な項目は変更のログに値を出さない
+	secret bool
+}
+
+var fields = []field{
+	{key: "port", env: "PORT", flag: "port", usage: "port to listen on",
+		get: func(c *Config) string { return c.port },
+		set: func(c *Config, v string) error { c.port = v; return nil }},
+	{key: "listen_addr", env: "LISTEN_ADDR", flag: "addr", usage: "address to listen on (overrides port)",
+		get: func(c *Config) string { return c.listenAddr },
+		set: func(c *Config, v string) error { c.listenAddr = v; return nil }},
+	{key: "unix_socket", env: "UNIX_SOCKET", flag: "unix-socket", usage: "additionally listen on this Unix domain socket",
+		get: func(c *Config) string { return c.unixSocket },
+		set: func(c *Config, v string) error { c.unixSocket = v; return nil }},
+	{key: "admin_addr", env: "ADMIN_ADDR", flag: "admin-addr", usage: "address of the admin HTTP server for health checks",
+		get: func(c *Config) string { return c.adminAddr },
+		set: func(c *Config, v string) error { c.adminAddr = v; return nil }},
+	{key: "env", env: "ENV", flag: "env", usage: "environment name",
+		get: func(c *Config) string { return c.env },
+		set: func(c *Config, v string) error { c.env = v; return nil }},
+	{key: "storage", env: "STORAGE", flag: "storage", usage: "where to store data (database, memory)",
+		get: func(c *Config) string { return c.storage },
+		set: func(c *Config, v string) error { c.storage = v; return nil }},
+
+	{key: "db.driver", env: "DB_DRIVER", flag: "db-driver", usage: "database driver (mysql, postgres, sqlite)",
+		get: func(c *Config) string { return c.dbDriver },
+		set: func(c *Config, v string) error { c.dbDriver = v; return nil }},
+	{key: "db.user", env: "DB_USER", flag: "db-user", usage: "database user",
+		get: func(c *Config) string { return c.dbUser },
+		set: func(c *Config, v string) error { c.dbUser = v; return nil }},
+	// パスワードはプロセス一覧から見えてしまうためフラグでは指定できない
+	{key: "db.password", env: "DB_PASSWORD", secret: true,
+		get: func(c *Config) string { return c.dbPassword },
+		set: func(c *Config, v string) error { c.dbPassword = v; return nil }},
+	{key: "db.name", env: "DB_NAME", flag: "db-name", usage: "database name",
+		get: func(c *Config) string { return c.dbName },
+		set: func(c *Config, v string) error { c.dbName = v; return nil }},
+	{key: "db.addr", env: "DB_ADDR", flag: "db-addr", usage: "database address (host:port)",
+		get: func(c *Config) string { return c.dbAddr },
+		set: func(c *Config, v string) error { c.dbAddr = v; return nil }},
+	{key: "db.timezone", env: "DB_TIMEZONE", flag: "db-timezone", usage: "time zone of the database connection (e.g. UTC, Asia/Tokyo)",
+		get: func(c *Config) string { return c.dbLocation.String() },
+		set: func(c *Config, v string) (err error) { c.dbLocation, err = time.LoadLocation(v); return err }},
+	{key: "db.replicas", env: "DB_REPLICAS", flag: "db-replicas", usage: "comma separated addresses of read replicas (host:port)",
+		get: func(c *Config) string { return strings.Join(c.dbReplicas, ",") },
+		set: func(c *Config, v string) error { c.dbReplicas = splitList(v); return nil }},
+	{key: "db.replica_sticky_window", env: "DB_REPLICA_STICKY_WINDOW", flag: "db-replica-sticky-window", usage: "how long reads of a user go to the primary after the user writes",
+		get: func(c *Config) string { return c.dbReplicaStickyWindow.String() },
+		set: func(c *Config, v string) (err error) {
+			c.dbReplicaStickyWindow, err = time.ParseDuration(v)
+			return err
+		}},
+	{key: "db.max_open_conns", env: "DB_MAX_OPEN_CONNS", flag: "db-max-open-conns", kind: kindInt, usage: "maximum number of open connections, 0 means unlimited",
+		get: func(c *Config) string { return strconv.Itoa(c.dbMaxOpenConns) },
+		set: func(c *Config, v string) (err error) { c.dbMaxOpenConns, err = strconv.Atoi(v); return err }},
+	{key: "db.max_idle_conns", env: "DB_MAX_IDLE_CONNS", flag: "db-max-idle-conns", kind: kindInt, usage: "maximum number of idle connections",
+		get: func(c *Config) string { return strconv.Itoa(c.dbMaxIdleConns) },
+		set: func(c *Config, v string) (err error) { c.dbMaxIdleConns, err = strconv.Atoi(v); return err }},
+	{key: "db.conn_max_lifetime", env: "DB_CONN_MAX_LIFETIME", flag: "db-conn-max-lifetime", usage: "maximum lifetime of a connection, 0 means forever",
+		get: func(c *Config) string { return c.dbConnMaxLifetime.String() },
+		set: func(c *Config, v string) (err error) { c.dbConnMaxLifetime, err = time.ParseDuration(v); return err }},
+	{key: "db.conn_max_idle_time", env: "DB_CONN_MAX_IDLE_TIME", flag: "db-conn-max-idle-time", usage: "maximum idle time of a connection, 0 means forever",
+		get: func(c *Config) string { return c.dbConnMaxIdleTime.String() },
+		set: func(c *Config, v string) (err error) { c.dbConnMaxIdleTime, err = time.ParseDuration(v); return err }},
+	{key: "db.health_interval", env: "DB_HEALTH_INTERVAL", flag: "db-health-interval", usage: "interval of database health checks",
+		get: func(c *Config) string { return c.dbHealthInterval.String() },
+		set: func(c *Config, v string) (err error) { c.dbHealthInterval, err = time.ParseDuration(v); return err }},
+	{key: "db.connect_timeout", env: "DB_CONNECT_TIMEOUT", flag: "db-connect-timeout", usage: "how long to retry connecting to the database at startup, 0 disables retry",
+		get: func(c *Config) string { return c.dbConnectTimeout.String() },
+		set: func(c *Config, v string) (err error) { c.dbConnectTimeout, err = time.ParseDuration(v); return err }},
+	{key: "db.slow_query_threshold", env: "DB_SLOW_QUERY_THRESHOLD", flag: "db-slow-query-threshold", usage: "log queries taking this long or longer, 0 disables the log",
+		get: func(c *Config) string { return c.dbSlowQueryThreshold.String() },
+		set: func(c *Config, v string) (err error) { c.dbSlowQueryThreshold, err = time.ParseDuration(v); return err }},
+
+	{key: "cache.size", env: "CACHE_SIZE", flag: "cache-size", kind: kindInt, usage: "maximum number of sessions and articles cached in memory, 0 disables the cache",
+		get: func(c *Config) string { return strconv.Itoa(c.cacheSize) },
+		set: func(c *Config, v string) (err error) { c.cacheSize, err = strconv.Atoi(v); return err }},
+	{key: "cache.session_ttl", env: "CACHE_SESSION_TTL", flag: "cache-session-ttl", usage: "how long sessions are cached",
+		get: func(c *Config) string { return c.cacheSessionTTL.String() },
+		set: func(c *Config, v string) (err error) { c.cacheSessionTTL, err = time.ParseDuration(v); return err }},
+	{key: "cache.article_ttl", env: "CACHE_ARTICLE_TTL", flag: "cache-article-ttl", usage: "how long articles are cached",
+		get: func(c *Config) string { return c.cacheArticleTTL.String() },
+		set: func(c *Config, v string) (err error) { c.cacheArticleTTL, err = time.ParseDuration(v); return err }},
+
+	{key: "tls.cert_file", env: "TLS_CERT_FILE", flag: "tls-cert", usage: "TLS certificate file",
+		get: func(c *Config) string { return c.tlsCertFile },
+		set: func(c *Config, v string) error { c.tlsCertFile = v; return nil }},
+	{key: "tls.key_file", env: "TLS_KEY_FILE", flag: "tls-key", usage: "TLS private key file",
+		get: func(c *Config) string { return c.tlsKeyFile },
+		set: func(c *Config, v string) error { c.tlsKeyFile = v; return nil }},
+	{key: "reflection", env: "REFLECTION", flag: "reflection", kind: kindBool, usage: "register the gRPC reflection service",
+		get: func(c *Config) string { return strconv.FormatBool(c.reflection) },
+		set: func(c *Config, v string) (err error) { c.reflection, err = strconv.ParseBool(v); return err }},
+
+	{key: "attachment.dir", env: "ATTACHMENT_DIR", flag: "attachment-dir", usage: "directory to store attachments",
+		get: func(c *Config) string { return c.attachmentDir },
+		set: func(c *Config, v string) error { c.attachmentDir = v; return nil }},
+	{key: "attachment.max_size", env: "ATTACHMENT_MAX_SIZE", flag: "attachment-max-size", kind: kindInt, usage: "maximum attachment size in bytes",
+		get: func(c *Config) string { return strconv.FormatInt(c.attachmentMaxSize, 10) },
+		set: func(c *Config, v string) (err error) {
+			c.attachmentMaxSize, err = strconv.ParseInt(v, 10, 64)
+			return err
+		}},
+	{key: "attachment.allowed_types", env: "ATTACHMENT_ALLOWED_TYPES", flag: "attachment-allowed-types", usage: "comma separated content types allowed as attachments",
+		get: func(c *Config) string { return strings.Join(c.attachmentAllowedTypes, ",") },
+		set: func(c *Config, v string) error { c.attachmentAllowedTypes = splitList(v); return nil }},
+
+	{key: "thumbnail.sizes", env: "THUMBNAIL_SIZES", flag: "thumbnail-sizes", usage: "comma separated thumbnail sizes in pixels",
+		get: func(c *Config) string { return joinInts(c.thumbnailSizes) },
+		set: func(c *Config, v string) (err error) { c.thumbnailSizes, err = parseInts(v); return err }},
+	{key: "thumbnail.workers", env: "THUMBNAIL_WORKERS", flag: "thumbnail-workers", kind: kindInt, usage: "number of thumbnail workers",
+		get: func(c *Config) string { return strconv.Itoa(c.thumbnailWorkers) },
+		set: func(c *Config, v string) (err error) { c.thumbnailWorkers, err = strconv.Atoi(v); return err }},
+
+	{key: "secret.provider", env: "SECRET_PROVIDER", flag: "secret-provider", usage: "where to read database credentials from (env, file, encrypted)",
+		get: func(c *Config) string { return c.secretProvider },
+		set: func(c *Config, v string) error { c.secretProvider = v; return nil }},
+	{key: "secret.dir", env: "SECRET_DIR", flag: "secret-dir", usage: "directory of secret files for the file provider",
+		get: func(c *Config) string { return c.secretDir },
+		set: func(c *Config, v string) error { c.secretDir = v; return nil }},
+	{key: "secret.file", env: "SECRET_FILE", flag: "secret-file", usage: "encrypted secret file for the encrypted provider",
+		get: func(c *Config) string { return c.secretFile },
+		set: func(c *Config, v string) error { c.secretFile = v; return nil }},
+	{key: "secret.key_file", env: "SECRET_KEY_FILE", flag: "secret-key-file", usage: "key file to decrypt the secret file",
+		get: func(c *Config) string { return c.secretKeyFile },
+		set: func(c *Config, v string) error { c.secretKeyFile = v; return nil }},
+	{key: "secret.refresh_interval", env: "SECRET_REFRESH_INTERVAL", flag: "secret-refresh-interval", usage: "interval to re-read database credentials, 0 disables refresh",
+		get: func(c *Config) string { return c.secretRefreshInterval.String() },
+		set: func(c *Config, v string) (err error) {
+			c.secretRefreshInterval, err = time.ParseDuration(v)
+			return err
+		}},
+
+	{key: "log_level", env: "LOG_LEVEL", flag: "log-level", usage: "log level (debug, info, warn, error)", reloadable: true,
+		get: func(c *Config) string { return c.logLevel },
+		set: func(c *Config, v string) error { c.logLevel = strings.ToLower(v); return nil }},
+	{key: "rate_limit.rps", env: "RATE_LIMIT_RPS", flag: "rate-limit-rps", usage: "requests per second allowed per client, 0 disables the limit", reloadable: true,
+		get: func(c *Config) string { return strconv.FormatFloat(c.rateLimitRPS, 'f', -1, 64) },
+		set: func(c *Config, v string) (err error) { c.rateLimitRPS, err = strconv.ParseFloat(v, 64); return err }},
+	{key: "rate_limit.burst", env: "RATE_LIMIT_BURST", flag: "rate-limit-burst", kind: kindInt, usage: "burst size of the rate limit", reloadable: true,
+		get: func(c *Config) string { return strconv.Itoa(c.rateLimitBurst) },
+		set: func(c *Config, v string) (err error) { c.rateLimitBurst, err = strconv.Atoi(v); return err }},
+	{key: "session.lifetime", env: "SESSION_LIFETIME", flag: "session-lifetime", usage: "lifetime of access tokens (e.g. 24h)", reloadable: true,
+		get: func(c *Config) string { return c.sessionLifetime.String() },
+		set: func(c *Config, v string) (err error) { c.sessionLifetime, err = time.ParseDuration(v); return err }},
+}
+
+func lookupField(key string) *field {
+	for i := range fields {
+		if fields[i].key == key {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// RegisterFlags は設定を上書きするフラグを登録する
+func RegisterFlags(fs *pflag.FlagSet) {
+	def := Default()
+
+	fs.String(FileFlag, "", "config file (YAML or TOML, default: CONFIG_FILE)")
+
+	for _, f := range fields {
+		if f.flag == "" {
+			continue
+		}
+
+		usage := fmt.Sprintf("%s (env: %s)", f.usage, f.env)
+		switch f.kind {
+		case kindBool:
+			b, _ := strconv.ParseBool(f.get(def))
+			fs.Bool(f.flag, b, usage)
+		case kindInt:
+			n, _ := strconv.ParseInt(f.get(def), 10, 64)
+			fs.Int64(f.flag, n, usage)
+		default:
+			fs.String(f.flag, f.get(def), usage)
+		}
+	}
+}
+
+type Options struct {
+	// File は設定ファイルのパス。空の場合は--configフラグ、CONFIG_FILEの順に参照する
+	File string
+
+	// Flags のうち、明示的に指定されたフラグだけを反映する
+	Flags *pflag.FlagSet
+}
+
+// path は読み込む設定ファイルのパスを返す。指定されていない場合は空文字
+func (o Options) path() string {
+	if o.File != "" {
+		return o.File
+	}
+	if o.Flags != nil {
+		if f := o.Flags.Lookup(FileFlag); f != nil && f.Value.String() != "" {
+			return f.Value.String()
+		}
+	}
+	return os.Getenv("CONFIG_FILE")
+}
+
+// Load はデフォルト値、設定ファイル、環境変数、フラグの順に値を重ねて設定を読み込み、検証する
+func Load(opts Options) (*Config, error) {
+	c := Default()
+
+	var errs []error
+
+	path := opts.path()
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			f := lookupField(k)
+			if f == nil {
+				errs = append(errs, fmt.Errorf("%s: unknown key in %s", k, path))
+				continue
+			}
+			if err := f.set(c, values[k]); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid value %q in %s", k, values[k], path))
+			}
+		}
+	}
+
+	for _, f := range fields {
+		v, err := lookupEnv(f.env)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if v == "" {
+			continue
+		}
+		if err := f.set(c, v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q", f.env, v))
+		}
+	}
+
+	if opts.Flags != nil {
+		for _, f := range fields {
+			if f.flag == "" {
+				continue
+			}
+			fl := opts.Flags.Lookup(f.flag)
+			if fl == nil || !fl.Changed {
+				continue
+			}
+			if err := f.set(c, fl.Value.String()); err != nil {
+				errs = append(errs, fmt.Errorf("--%s: invalid value %q", f.flag, fl.Value.String()))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Validate は起動に必要な項目と値の範囲を検証し、問題をまとめて返す
+func (c *Config) Validate() error {
+	var errs []error
+
+	invalid := func(key, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s (set %s)", key, fmt.Sprintf(format, args...), lookupField(key).sources()))
+	}
+
+	if port, err := strconv.Atoi(c.port); err != nil || port < 1 || port > 65535 {
+		invalid("port", "must be between 1 and 65535, got %q", c.port)
+	}
+	if c.listenAddr != "" && !isHostPort(c.listenAddr) {
+		invalid("listen_addr", "must be host:port, got %q", c.listenAddr)
+	}
+	if c.adminAddr != "" {
+		if !isHostPort(c.adminAddr) {
+			invalid("admin_addr", "must be host:port, got %q", c.adminAddr)
+		} else if c.adminAddr == c.GetListenAddr() {
+			invalid("admin_addr", "must differ from the gRPC listen address")
+		}
+	}
+
+	switch c.storage {
+	case StorageDatabase:
+		c.validateDB(invalid)
+	case StorageMemory:
+	default:
+		invalid("storage", "must be one of database or memory, got %q", c.storage)
+	}
+
+	if c.cacheSize < 0 {
+		invalid("cache.size", "must be 0 or more, got %d", c.cacheSize)
+	}
+	if c.cacheSize > 0 && c.cacheSessionTTL <= 0 {
+		invalid("cache.session_ttl", "must be more than 0 when the cache is enabled, got %s", c.cacheSessionTTL)
+	}
+	if c.cacheSize > 0 && c.cacheArticleTTL <= 0 {
+		invalid("cache.article_ttl", "must be more than 0 when the cache is enabled, got %s", c.cacheArticleTTL)
+	}
+
+	if (c.tlsCertFile == "") != (c.tlsKeyFile == "") {
+		invalid("tls.cert_file", "tls.cert_file and tls.key_file must be set together")
+	}
+
+	if c.attachmentDir == "" {
+		invalid("attachment.dir", "is required")
+	}
+	if c.attachmentMaxSize < 1 || c.attachmentMaxSize > 1<<30 {
+		invalid("attachment.max_size", "must be between 1 and %d bytes, got %d", 1<<30, c.attachmentMaxSize)
+	}
+	for _, t := range c.attachmentAllowedTypes {
+		if _, _, err := mime.ParseMediaType(t); err != nil {
+			invalid("attachment.allowed_types", "%q is not a valid content type", t)
+		}
+	}
+
+	for _, size := range c.thumbnailSizes {
+		if size < 16 || size > 4096 {
+			invalid("thumbnail.sizes", "each size must be between 16 and 4096, got %d", size)
+		}
+	}
+	if c.thumbnailWorkers < 1 || c.thumbnailWorkers > 64 {
+		invalid("thumbnail.workers", "must be between 1 and 64, got %d", c.thumbnailWorkers)
+	}
+
+	switch c.secretProvider {
+	case "env":
+	case "file":
+		if c.secretDir == "" {
+			invalid("secret.dir", "is required for the file provider")
+		}
+	case "encrypted":
+		if c.secretFile == "" {
+			invalid("secret.file", "is required for the encrypted provider")
+		}
+		if c.secretKeyFile == "" {
+			invalid("secret.key_file", "is required for the encrypted provider")
+		}
+	default:
+		invalid("secret.provider", "must be one of env, file or encrypted, got %q", c.secretProvider)
+	}
+	if c.secretRefreshInterval != 0 && c.secretRefreshInterval < 10*time.Second {
+		invalid("secret.refresh_interval", "must be 0 or 10s or more, got %s", c.secretRefreshInterval)
+	}
+
+	switch c.logLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		invalid("log_level", "must be one of debug, info, warn or error, got %q", c.logLevel)
+	}
+	if c.rateLimitRPS < 0 {
+		invalid("rate_limit.rps", "must be 0 or more, got %v", c.rateLimitRPS)
+	}
+	if c.rateLimitRPS > 0 && c.rateLimitBurst < 1 {
+		invalid("rate_limit.burst", "must be 1 or more when the rate limit is enabled, got %d", c.rateLimitBurst)
+	}
+	if c.sessionLifetime < time.Minute || c.sessionLifetime > 30*24*time.Hour {
+		invalid("session.lifetime", "must be between 1m and 720h, got %s", c.sessionLifetime)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// validateDB はデータベースに接続する場合だけ必要な設定を検証する
+func (c *Config) validateDB(invalid func(key, format string, args ...interface{})) {
+	switch c.dbDriver {
+	case DBDriverMySQL, DBDriverPostgres, DBDriverSQLite:
+	default:
+		invalid("db.driver", "must be one of mysql, postgres or sqlite, got %q", c.dbDriver)
+	}
+	if c.dbName == "" {
+		invalid("db.name", "is required")
+	}
+
+	// SQLiteはdb.nameのファイルを開くだけで、接続先や認証情報を使わない
+	if c.dbDriver == DBDriverSQLite {
+		if len(c.dbReplicas) > 0 {
+			invalid("db.replicas", "is not supported with sqlite")
+		}
+	} else {
+		if c.dbUser == "" {
+			invalid("db.user", "is required")
+		}
+		if c.dbAddr == "" {
+			invalid("db.addr", "is required")
+		} else if !isHostPort(c.dbAddr) {
+			invalid("db.addr", "must be host:port, got %q", c.dbAddr)
+		}
+	}
+
+	for _, addr := range c.dbReplicas {
+		if !isHostPort(addr) {
+			invalid("db.replicas", "each replica must be host:port, got %q", addr)
+		} else if addr == c.dbAddr {
+			invalid("db.replicas", "must not include the primary %q", addr)
+		}
+	}
+	if c.dbSlowQueryThreshold < 0 {
+		invalid("db.slow_query_threshold", "must be 0 or more, got %s", c.dbSlowQueryThreshold)
+	}
+	if c.dbReplicaStickyWindow < 0 {
+		invalid("db.replica_sticky_window", "must be 0 or more, got %s", c.dbReplicaStickyWindow)
+	}
+
+	if c.dbMaxOpenConns < 0 {
+		invalid("db.max_open_conns", "must be 0 or more, got %d", c.dbMaxOpenConns)
+	}
+	if c.dbMaxIdleConns < 0 {
+		invalid("db.max_idle_conns", "must be 0 or more, got %d", c.dbMaxIdleConns)
+	} else if c.dbMaxOpenConns > 0 && c.dbMaxIdleConns > c.dbMaxOpenConns {
+		invalid("db.max_idle_conns", "must not exceed db.max_open_conns (%d), got %d", c.dbMaxOpenConns, c.dbMaxIdleConns)
+	}
+	if c.dbConnMaxLifetime < 0 {
+		invalid("db.conn_max_lifetime", "must be 0 or more, got %s", c.dbConnMaxLifetime)
+	}
+	if c.dbConnMaxIdleTime < 0 {
+		invalid("db.conn_max_idle_time", "must be 0 or more, got %s", c.dbConnMaxIdleTime)
+	}
+	if c.dbHealthInterval < time.Second {
+		invalid("db.health_interval", "must be 1s or more, got %s", c.dbHealthInterval)
+	}
+	if c.dbConnectTimeout < 0 {
+		invalid("db.connect_timeout", "must be 0 or more, got %s", c.dbConnectTimeout)
+	}
+}
+
+// lookupEnv は環境変数nameの値を返す。name_FILEが指定されていれば、そのファイルの内容を値とする。
+// DockerやKubernetesのシークレットをファイルとしてマウントした場合に使う
+func lookupEnv(name string) (string, error) {
+	v := os.Getenv(name)
+
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return v, nil
+	}
+	if v != "" {
+		return "", fmt.Errorf("%s: %s and %s_FILE must not be set at the same time", name, name, name)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s_FILE: %v", name, err)
+	}
+
+	// echoなどで作成したファイルの末尾の改行は値に含めない
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// sources はエラーメッセージ用に、項目を指定できる場所を列挙する
+func (f *field) sources() string {
+	s := fmt.Sprintf("%s in the config file or %s or %s_FILE", f.key, f.env, f.env)
+	if f.flag != "" {
+		s += " or --" + f.flag
+	}
+	return s
+}
+
+// readFile は設定ファイルを読み込み、入れ子のキーを.で連結した平坦なマップにする
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read config file: %v", err)
+	}
+
+	raw := make(map[string]interface{})
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, xerrors.Errorf("unsupported config file format: %s", path)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten("", raw, values)
+
+	return values, nil
+}
+
+func flatten(prefix string, raw map[string]interface{}, values map[string]string) {
+	for k, v := range raw {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		switch v := v.(type) {
+		case map[string]interface{}:
+			flatten(key, v, values)
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+func isHostPort(addr string) bool {
+	_, port, err := net.SplitHostPort(addr)
+	return err == nil && port != ""
+}
+
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseInts(v string) ([]int, error) {
+	var ns []int
+	for _, item := range splitList(v) {
+		n, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, err
+		}
+		ns = append(ns, n)
+	}
+	return ns, nil
+}
+
+func joinInts(ns []int) string {
+	items := make([]string, 0, len(ns))
+	for _, n := range ns {
+		items = append(items, strconv.Itoa(n))
+	}
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func requiredEnv(t *testing.T) {
+	t.Setenv("DB_USER", "root")
+	t.Setenv("DB_NAME", "app")
+	t.Setenv("DB_ADDR", "127.0.0.1:3306")
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("優先順位", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", `
+port: 9000
+db:
+  user: file_user
+  name: file_db
+  addr: file:3306
+thumbnail:
+  sizes: [64, 256]
+  workers: 4
+`)
+		t.Setenv("DB_USER", "env_user")
+		t.Setenv("THUMBNAIL_WORKERS", "8")
+
+		fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		RegisterFlags(fs)
+		if err := fs.Parse([]string{"--config", path, "--thumbnail-workers", "16"}); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := Load(Options{Flags: fs})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if c.GetPort() != "9000" {
+			t.Errorf("file value should be used: %v", c.GetPort())
+		}
+		if c.GetDBUser() != "env_user" {
+			t.Errorf("env should override file: %v", c.GetDBUser())
+		}
+		if c.GetThumbnailWorkers() != 16 {
+			t.Errorf("flag should override env: %v", c.GetThumbnailWorkers())
+		}
+		if !reflect.DeepEqual(c.GetThumbnailSizes(), []int{64, 256}) {
+			t.Errorf("list should be read from file: %v", c.GetThumbnailSizes())
+		}
+		if c.GetAttachmentMaxSize() != defaultAttachmentMaxSize {
+			t.Errorf("default should be kept: %v", c.GetAttachmentMaxSize())
+		}
+	})
+
+	t.Run("TOML", func(t *testing.T) {
+		requiredEnv(t)
+		path := writeFile(t, "config.toml", `
+listen_addr = "127.0.0.1:9000"
+
+[attachment]
+allowed_types = ["image/png"]
+`)
+
+		c, err := Load(Options{File: path})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if c.GetListenAddr() != "127.0.0.1:9000" {
+			t.Errorf("Expect: %v, Got: %v", "127.0.0.1:9000", c.GetListenAddr())
+		}
+		if !reflect.DeepEqual(c.GetAttachmentAllowedTypes(), []string{"image/png"}) {
+			t.Errorf("Expect: %v, Got: %v", []string{"image/png"}, c.GetAttachmentAllowedTypes())
+		}
+	})
+
+	t.Run("未知のキー", func(t *testing.T) {
+		requiredEnv(t)
+		path := writeFile(t, "config.yaml", "db:\n  usr: typo\n")
+
+		_, err := Load(Options{File: path})
+		if err == nil || !strings.Contains(err.Error(), "db.usr: unknown key") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("型が不正な環境変数", func(t *testing.T) {
+		requiredEnv(t)
+		t.Setenv("ATTACHMENT_MAX_SIZE", "ten")
+
+		_, err := Load(Options{})
+		if err == nil || !strings.Contains(err.Error(), "ATTACHMENT_MAX_SIZE") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("_FILEで指定したファイルから読み込む", func(t *testing.T) {
+		requiredEnv(t)
+		t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db_password", "s3cret\n"))
+
+		c, err := Load(Options{})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if c.GetDBPassword() != "s3cret" {
+			t.Errorf("Expect: %v, Got: %q", "s3cret", c.GetDBPassword())
+		}
+	})
+
+	t.Run("環境変数と_FILEの両方を指定", func(t *testing.T) {
+		requiredEnv(t)
+		t.Setenv("DB_PASSWORD", "password")
+		t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db_password", "s3cret"))
+
+		_, err := Load(Options{})
+		if err == nil || !strings.Contains(err.Error(), "must not be set at the same time") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := func() *Config {
+		c := Default()
+		c.dbUser, c.dbName, c.dbAddr = "root", "app", "127.0.0.1:3306"
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		expect string
+	}{
+		{name: "正常", modify: func(c *Config) {}},
+		{name: "必須項目の不足", modify: func(c *Config) { c.dbUser = "" }, expect: "db.user: is required"},
+		{name: "ポートの範囲外", modify: func(c *Config) { c.port = "0" }, expect: "port: must be between"},
+		{name: "メモリに保存する場合はデータベースの設定は不要", modify: func(c *Config) { c.storage, c.dbUser, c.dbAddr = StorageMemory, "", "" }},
+		{name: "保存先の指定が不正", modify: func(c *Config) { c.storage = "file" }, expect: "storage: must be one of"},
+		{name: "未対応のデータベース", modify: func(c *Config) { c.dbDriver = "oracle" }, expect: "db.driver: must be one of"},
+		{name: "SQLiteは接続先が不要", modify: func(c *Config) { c.dbDriver, c.dbUser, c.dbAddr = DBDriverSQLite, "", "" }},
+		{name: "SQLiteはレプリカに未対応", modify: func(c *Config) { c.dbDriver, c.dbReplicas = DBDriverSQLite, []string{"127.0.0.1:3307"} }, expect: "db.replicas: is not supported"},
+		{name: "アドレスの形式", modify: func(c *Config) { c.dbAddr = "localhost" }, expect: "db.addr: must be host:port"},
+		{name: "管理用ポートの重複", modify: func(c *Config) { c.adminAddr = ":8080" }, expect: "admin_addr: must differ"},
+		{name: "TLSの片方だけ指定", modify: func(c *Config) { c.tlsCertFile = "server.crt" }, expect: "tls.cert_file"},
+		{name: "キャッシュの件数が負", modify: func(c *Config) { c.cacheSize = -1 }, expect: "cache.size: must be 0 or more"},
+		{name: "キャッシュを使う場合は保存期間が必要", modify: func(c *Config) { c.cacheSize, c.cacheArticleTTL = 100, 0 }, expect: "cache.article_ttl"},
+		{name: "サムネイルサイズの範囲外", modify: func(c *Config) { c.thumbnailSizes = []int{8} }, expect: "thumbnail.sizes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.modify(c)
+
+			err := c.Validate()
+			if tt.expect == "" {
+				if err != nil {
+					t.Errorf("err should be nil: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Errorf("Expect: %v, Got: %v", tt.expect, err)
+			}
+		})
+	}
+}
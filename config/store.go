@@ -0,0 +1,88 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Change は再読み込みで値が変わった設定項目
+type Change struct {
+	Key string
+	Old string
+	New string
+
+	// Applied がfalseの項目は再起動するまで反映されない
+	Applied bool
+}
+
+// Store は起動中に差し替えられる設定を保持する。
+// 読み出し側はリクエストごとにLoadを呼び、その時点の設定を使う
+type Store struct {
+	mu        sync.Mutex
+	current   atomic.Pointer[Config]
+	listeners []func(*Config)
+}
+
+func NewStore(c *Config) *Store {
+	s := &Store{}
+	s.current.Store(c)
+	return s
+}
+
+// Load は現在の設定を返す。返した設定は変更されないため、処理の途中で値が変わることはない
+func (s *Store) Load() *Config {
+	return s.current.Load()
+}
+
+// OnChange は設定が差し替えられるたびに新しい設定でfnを呼ぶ
+func (s *Store) OnChange(fn func(*Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listeners = append(s.listeners, fn)
+}
+
+// Apply はnextを検証し、再読み込みできる項目だけを現在の設定に反映して差し替える。
+// 検証に失敗した場合は何も変更しない
+func (s *Store) Apply(next *Config) ([]Change, error) {
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur := s.current.Load()
+	merged := *cur
+
+	var changes []Change
+	applied := false
+	for _, f := range fields {
+		old, v := f.get(cur), f.get(next)
+		if old == v {
+			continue
+		}
+
+		change := Change{Key: f.key, Old: old, New: v, Applied: f.reloadable}
+		if f.secret {
+			change.Old, change.New = "***", "***"
+		}
+		changes = append(changes, change)
+
+		if f.reloadable {
+			if err := f.set(&merged, v); err != nil {
+				return nil, err
+			}
+			applied = true
+		}
+	}
+
+	if applied {
+		s.current.Store(&merged)
+		for _, fn := range s.listeners {
+			fn(&merged)
+		}
+	}
+
+	return changes, nil
+}
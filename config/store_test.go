@@ -0,0 +1,108 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStore_Apply(t *testing.T) {
+	base := func() *Config {
+		c := Default()
+		c.dbUser, c.dbName, c.dbAddr = "root", "app", "127.0.0.1:3306"
+		return c
+	}
+
+	t.Run("再読み込みできる項目だけ反映する", func(t *testing.T) {
+		s := NewStore(base())
+		prev := s.Load()
+
+		var notified *Config
+		s.OnChange(func(c *Config) { notified = c })
+
+		next := base()
+		next.logLevel = "debug"
+		next.sessionLifetime = time.Hour
+		next.port = "9000"
+		next.dbPassword = "secret"
+
+		changes, err := s.Apply(next)
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		c := s.Load()
+		if c.GetLogLevel() != "debug" || c.GetSessionLifetime() != time.Hour {
+			t.Errorf("reloadable fields should be applied: %v %v", c.GetLogLevel(), c.GetSessionLifetime())
+		}
+		if c.GetPort() != defaultPort || c.GetDBPassword() != "" {
+			t.Errorf("other fields should be kept: %v %v", c.GetPort(), c.GetDBPassword())
+		}
+		if notified != c {
+			t.Error("listener should be called with the new config")
+		}
+		if prev.GetLogLevel() != defaultLogLevel {
+			t.Errorf("previous config should not be modified: %v", prev.GetLogLevel())
+		}
+
+		got := map[string]Change{}
+		for _, c := range changes {
+			got[c.Key] = c
+		}
+		if len(got) != 4 {
+			t.Errorf("changes should have 4 items: %+v", changes)
+		}
+		if !got["log_level"].Applied || got["port"].Applied {
+			t.Errorf("applied flag is wrong: %+v", changes)
+		}
+		if got["db.password"].New != "***" {
+			t.Errorf("secret should be masked: %+v", got["db.password"])
+		}
+	})
+
+	t.Run("検証に失敗した場合は何も変更しない", func(t *testing.T) {
+		s := NewStore(base())
+
+		next := base()
+		next.logLevel = "verbose"
+
+		if _, err := s.Apply(next); err == nil {
+			t.Error("err should not be nil")
+		}
+		if s.Load().GetLogLevel() != defaultLogLevel {
+			t.Errorf("config should not be changed: %v", s.Load().GetLogLevel())
+		}
+	})
+}
+
+func TestWatch(t *testing.T) {
+	requiredEnv(t)
+	path := writeFile(t, "config.yaml", "log_level: info\n")
+
+	c, err := Load(Options{File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewStore(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- Watch(ctx, s, Options{File: path}) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// 監視を開始する前に書き込むと取りこぼすため、反映されるまで間隔を空けて書き込みを繰り返す
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Load().GetLogLevel() != "warn" {
+		if time.Now().After(deadline) {
+			t.Fatal("config file change should be applied")
+		}
+		if err := os.WriteFile(path, []byte("log_level: warn\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"sample-grpc-server/logger"
+
+	"github.com/fsnotify/fsnotify"
+	"golang.org/x/xerrors"
+)
+
+// エディタは保存時に書き込みやリネームを連続して行うため、落ち着いてから一度だけ読み込む
+const reloadDelay = 200 * time.Millisecond
+
+// Watch はSIGHUPを受け取るか設定ファイルが変更されるたびに設定を読み込み直してstoreに反映する。
+// ctxがキャンセルされるまで戻らない
+func Watch(ctx context.Context, store *Store, opts Options) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events <-chan fsnotify.Event
+	var watchErrs <-chan error
+
+	if path := opts.path(); path != "" {
+		w, err := fsnotify.NewWatcher()
+		if err != nil {
+			return xerrors.Errorf("failed to watch config file: %v", err)
+		}
+		defer w.Close()
+
+		// ファイルを置き換えて保存された場合も検知できるようディレクトリを監視する
+		if err := w.Add(filepath.Dir(path)); err != nil {
+			return xerrors.Errorf("failed to watch config file: %v", err)
+		}
+		events, watchErrs = w.Events, w.Errors
+
+		opts.File = filepath.Clean(path)
+	}
+
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			logger.Infof("received SIGHUP, reloading config")
+			Reload(store, opts)
+		case ev := <-events:
+			if filepath.Clean(ev.Name) != opts.File || ev.Op == fsnotify.Chmod {
+				continue
+			}
+			timer.Reset(reloadDelay)
+		case <-timer.C:
+			logger.Infof("%s changed, reloading config", opts.File)
+			Reload(store, opts)
+		case err := <-watchErrs:
+			logger.Warnf("failed to watch config file: %v", err)
+		}
+	}
+}
+
+// Reload は設定を読み込み直してstoreに反映し、変更内容をログに出す。
+// 読み込みか検証に失敗した場合は現在の設定を使い続ける
+func Reload(store *Store, opts Options) {
+	next, err := Load(opts)
+	if err != nil {
+		logger.Errorf("config was not reloaded: %v", err)
+		return
+	}
+
+	changes, err := store.Apply(next)
+	if err != nil {
+		logger.Errorf("config was not reloaded: %v", err)
+		return
+	}
+
+	if len(changes) == 0 {
+		logger.Infof("config reloaded with no changes")
+		return
+	}
+
+	for _, c := range changes {
+		if c.Applied {
+			logger.Infof("config %s changed: %s -> %s", c.Key, c.Old, c.New)
+		} else {
+			logger.Warnf("config %s changed: %s -> %s (requires restart, ignored)", c.Key, c.Old, c.New)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache はクエリの結果を保存する先。Redisなど外部のキャッシュを使う場合はこのインターフェースを実装する
+type Cache interface {
+	// Get はkeyの値を返す。保存されていないか期限切れの場合はfalseを返す
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set はkeyにvalueをttlの間保存する。ttlが0以下の場合は保存しない
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now()
+	c := NewLRU(2)
+	c.now = func() time.Time { return now }
+
+	c.Set(ctx, "a", []byte("a"), time.Minute)
+	c.Set(ctx, "b", []byte("b"), time.Minute)
+
+	// aを使うとbが最も古くなる
+	if _, ok, _ := c.Get(ctx, "a"); !ok {
+		t.Fatal("a should be found")
+	}
+	c.Set(ctx, "c", []byte("c"), time.Minute)
+
+	t.Run("上限を超えると最後に使われたのが最も古いものを捨てる", func(t *testing.T) {
+		if _, ok, _ := c.Get(ctx, "b"); ok {
+			t.Error("b should be evicted")
+		}
+		for _, key := range []string{"a", "c"} {
+			if v, ok, _ := c.Get(ctx, key); !ok || string(v) != key {
+				t.Errorf("Expect: %v, Got: %v, %v", key, string(v), ok)
+			}
+		}
+	})
+
+	t.Run("上書き", func(t *testing.T) {
+		c.Set(ctx, "a", []byte("updated"), time.Minute)
+
+		if v, _, _ := c.Get(ctx, "a"); string(v) != "updated" {
+			t.Errorf("Expect: %v, Got: %v", "updated", string(v))
+		}
+		if c.Len() != 2 {
+			t.Errorf("Expect: %v, Got: %v", 2, c.Len())
+		}
+	})
+
+	t.Run("削除", func(t *testing.T) {
+		c.Delete(ctx, "a", "unknown")
+
+		if _, ok, _ := c.Get(ctx, "a"); ok {
+			t.Error("a should be deleted")
+		}
+		if c.Len() != 1 {
+			t.Errorf("Expect: %v, Got: %v", 1, c.Len())
+		}
+	})
+
+	t.Run("期限切れ", func(t *testing.T) {
+		c.Set(ctx, "short", []byte("short"), time.Second)
+		now = now.Add(time.Second)
+
+		if _, ok, _ := c.Get(ctx, "short"); ok {
+			t.Error("expired entry should not be found")
+		}
+		if _, ok, _ := c.Get(ctx, "c"); !ok {
+			t.Error("c should be found")
+		}
+	})
+
+	t.Run("期間が0以下なら保存しない", func(t *testing.T) {
+		c.Set(ctx, "zero", []byte("zero"), 0)
+
+		if _, ok, _ := c.Get(ctx, "zero"); ok {
+			t.Error("zero should not be stored")
+		}
+	})
+}
@@ -0,0 +1,273 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/logger"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultSessionTTL = 30 * time.Second
+	defaultArticleTTL = time.Minute
+
+	// loadTimeout は同じkeyの呼び出し元で共有する取得にかける時間の上限
+	loadTimeout = 10 * time.Second
+)
+
+var _ database.Querier = (*Querier)(nil)
+
+// Querier はGetSessionとGetArticleの結果をCacheに保存するdatabase.Querier。
+// それ以外のメソッドはそのまま元のQuerierで実行し、記事の更新・削除とセッションの削除で該当する結果を無効化する
+type Querier struct {
+	database.Querier
+
+	cache      Cache
+	group      *singleflight.Group
+	sessionTTL time.Duration
+	articleTTL time.Duration
+
+	// generation は無効化のたびに増える。読み込み中に無効化された結果は古い可能性があるため保存しない
+	generation *atomic.Uint64
+
+	// pending はWithTxの中で使われる場合に、コミット後に無効化するキー
+	pending *[]string
+}
+
+type Option func(*Querier)
+
+// WithSessionTTL はセッションを保存する期間を指定する。セッションの有効期限より長くは保存しない
+func WithSessionTTL(d time.Duration) Option {
+	return func(q *Querier) {
+		q.sessionTTL = d
+	}
+}
+
+func WithArticleTTL(d time.Duration) Option {
+	return func(q *Querier) {
+		q.articleTTL = d
+	}
+}
+
+func NewQuerier(db database.Querier, c Cache, opts ...Option) *Querier {
+	q := &Querier{
+		Querier:    db,
+		cache:      c,
+		group:      new(singleflight.Group),
+		sessionTTL: defaultSessionTTL,
+		articleTTL: defaultArticleTTL,
+		generation: new(atomic.Uint64),
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	return q
+}
+
+// WithTx の中ではコミット前の内容を保存しないようキャッシュを使わずに読み、無効化はfnが終わってからまとめて行う
+func (q *Querier) WithTx(ctx context.Context, fn func(database.Querier) error) error {
+	if q.pending != nil {
+		return q.Querier.WithTx(ctx, func(tx database.Querier) error {
+			return fn(q.withTx(tx, q.pending))
+		})
+	}
+
+	var pending []string
+	err := q.Querier.WithTx(ctx, func(tx database.Querier) error {
+		return fn(q.withTx(tx, &pending))
+	})
+
+	// ロールバックされた場合も、失敗した試行で書き込んだ可能性があるため無効化する
+	q.invalidate(ctx, pending...)
+
+	return err
+}
+
+func (q *Querier) withTx(tx database.Querier, pending *[]string) *Querier {
+	c := *q
+	c.Querier = tx
+	c.pending = pending
+	return &c
+}
+
+func (q *Querier) GetSession(ctx context.Context, p database.GetSessionParams) (*database.GetSessionResult, error) {
+	if q.pending != nil {
+		return q.Querier.GetSession(ctx, p)
+	}
+
+	return load(ctx, q, sessionKey(p.AccessToken),
+		func(r *database.GetSessionResult) time.Duration {
+			if ttl := time.Until(r.ExpiredAt); ttl < q.sessionTTL {
+				return ttl
+			}
+			return q.sessionTTL
+		},
+		func(ctx context.Context) (*database.GetSessionResult, error) {
+			return q.Querier.GetSession(ctx, p)
+		},
+	)
+}
+
+func (q *Querier) DeleteSession(ctx context.Context, p database.DeleteSessionParams) error {
+	defer q.invalidate(ctx, sessionKey(p.AccessToken))
+
+	return q.Querier.DeleteSession(ctx, p)
+}
+
+func (q *Querier) GetArticle(ctx context.Context, p database.GetArticleParams) (*database.GetArticleResult, error) {
+	if q.pending != nil {
+		return q.Querier.GetArticle(ctx, p)
+	}
+
+	return load(ctx, q, articleKey(p.UserID, p.ArticleID),
+		func(*database.GetArticleResult) time.Duration { return q.articleTTL },
+		func(ctx context.Context) (*database.GetArticleResult, error) {
+			return q.Querier.GetArticle(ctx, p)
+		},
+	)
+}
+
+func (q *Querier) UpdateArticle(ctx context.Context, p database.UpdateArticleParams) error {
+	defer q.invalidate(ctx, articleKey(p.UserID, p.ArticleID))
+
+	return q.Querier.UpdateArticle(ctx, p)
+}
+
+func (q *Querier) UpdateArticleText(ctx context.Context, p database.UpdateArticleTextParams) error {
+	defer q.invalidate(ctx, articleKey(p.UserID, p.ArticleID))
+
+	return q.Querier.UpdateArticleText(ctx, p)
+}
+
+func (q *Querier) DeleteArticle(ctx context.Context, p database.DeleteArticleParams) error {
+	defer q.invalidate(ctx, articleKey(p.UserID, p.ArticleID))
+
+	return q.Querier.DeleteArticle(ctx, p)
+}
+
+func (q *Querier) BatchDeleteArticles(ctx context.Context, p database.BatchDeleteArticlesParams) (*database.BatchDeleteArticlesResult, error) {
+	keys := make([]string, 0, len(p.ArticleIDs))
+	for _, id := range p.ArticleIDs {
+		keys = append(keys, articleKey(p.UserID, id))
+	}
+	defer q.invalidate(ctx, keys...)
+
+	return q.Querier.BatchDeleteArticles(ctx, p)
+}
+
+// load はkeyに保存された結果を返す。保存されていなければfetchで取得して保存する。
+// 同じkeyの取得が同時に行われた場合は1回だけfetchを実行し、結果を共有する。
+// 最初の呼び出し元がキャンセルしても他の呼び出し元が失敗しないよう、fetchにはキャンセルを引き継がないctxを渡し、
+// それぞれの呼び出し元は自身のctxがキャンセルされるまで待つ
+func load[T any](ctx context.Context, q *Querier, key string, ttl func(*T) time.Duration, fetch func(context.Context) (*T, error)) (*T, error) {
+	b, ok, err := q.cache.Get(ctx, key)
+	if err != nil {
+		logger.Warnf("failed to get %s from cache: %v", key, err)
+	}
+	if ok {
+		v := new(T)
+		if err := json.Unmarshal(b, v); err == nil {
+			return v, nil
+		}
+		logger.Warnf("failed to decode %s from cache: %v", key, err)
+	}
+
+	ch := q.group.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(detached{ctx}, loadTimeout)
+		defer cancel()
+
+		gen := q.generation.Load()
+
+		v, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if q.generation.Load() != gen {
+			return v, nil
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			logger.Warnf("failed to encode %s: %v", key, err)
+			return v, nil
+		}
+		if err := q.cache.Set(ctx, key, b, ttl(v)); err != nil {
+			logger.Warnf("failed to set %s to cache: %v", key, err)
+		}
+
+		return v, nil
+	})
+
+	var v interface{}
+	select {
+	case r := <-ch:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		v = r.Val
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	// 同時に取得した呼び出し元同士で結果を書き換え合わないよう複製して返す
+	r := *v.(*T)
+	return &r, nil
+}
+
+// detached は元のctxの値を引き継ぎ、キャンセルと期限は引き継がないcontext
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
+
+func (q *Querier) invalidate(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	if q.pending != nil {
+		*q.pending = append(*q.pending, keys...)
+		return
+	}
+
+	q.generation.Add(1)
+	for _, key := range keys {
+		q.group.Forget(key)
+	}
+
+	if err := q.cache.Delete(ctx, keys...); err != nil {
+		logger.Warnf("failed to invalidate cache: %v", err)
+	}
+}
+
+// sessionKey は外部のキャッシュにアクセストークンをそのまま保存しないようハッシュにする
+func sessionKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "session:" + hex.EncodeToString(sum[:])
+}
+
+func articleKey(userID, articleID int64) string {
+	return fmt.Sprintf("article:%d:%d", userID, articleID)
+}
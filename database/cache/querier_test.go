@@ -0,0 +1,313 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/database/dbtest"
+	"sample-grpc-server/database/memory"
+
+	"github.com/uptrace/bun"
+)
+
+// countingQuerier はGetSessionとGetArticleが元のQuerierで実行された回数を数える
+type countingQuerier struct {
+	*memory.Querier
+
+	sessions atomic.Int64
+	articles atomic.Int64
+
+	// block が閉じられるかctxがキャンセルされるまでGetArticleを待たせる
+	block chan struct{}
+}
+
+func (q *countingQuerier) GetSession(ctx context.Context, p database.GetSessionParams) (*database.GetSessionResult, error) {
+	q.sessions.Add(1)
+	return q.Querier.GetSession(ctx, p)
+}
+
+func (q *countingQuerier) GetArticle(ctx context.Context, p database.GetArticleParams) (*database.GetArticleResult, error) {
+	q.articles.Add(1)
+	if q.block != nil {
+		select {
+		case <-q.block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return q.Querier.GetArticle(ctx, p)
+}
+
+func setup(t *testing.T) (*Querier, *countingQuerier, int64, int64) {
+	t.Helper()
+
+	ctx := context.Background()
+	db := &countingQuerier{Querier: memory.NewQuerier()}
+
+	user, err := db.SignUp(ctx, database.SignUpParams{Email: "test@example.com", Password: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	article, err := db.CreateArticle(ctx, database.CreateArticleParams{UserID: user.UserID, Title: "title", Text: "text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewQuerier(db, NewLRU(100)), db, user.UserID, article.ArticleID
+}
+
+func TestQuerier_GetArticle(t *testing.T) {
+	ctx := context.Background()
+	q, db, userID, articleID := setup(t)
+	params := database.GetArticleParams{ArticleID: articleID, UserID: userID}
+
+	getTitle := func(t *testing.T) string {
+		t.Helper()
+
+		resp, err := q.GetArticle(ctx, params)
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		return resp.Article.Title
+	}
+
+	t.Run("2回目以降は保存した結果を返す", func(t *testing.T) {
+		getTitle(t)
+		if title := getTitle(t); title != "title" {
+			t.Errorf("Expect: %v, Got: %v", "title", title)
+		}
+		if n := db.articles.Load(); n != 1 {
+			t.Errorf("Expect: %v, Got: %v", 1, n)
+		}
+	})
+
+	t.Run("他のユーザーには返さない", func(t *testing.T) {
+		_, err := q.GetArticle(ctx, database.GetArticleParams{ArticleID: articleID, UserID: userID + 1})
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expect: %v, Got: %v", sql.ErrNoRows, err)
+		}
+	})
+
+	t.Run("更新すると無効化する", func(t *testing.T) {
+		if err := q.UpdateArticle(ctx, database.UpdateArticleParams{ArticleID: articleID, UserID: userID, Title: "updated", Text: "text"}); err != nil {
+			t.Fatal(err)
+		}
+		if title := getTitle(t); title != "updated" {
+			t.Errorf("Expect: %v, Got: %v", "updated", title)
+		}
+	})
+
+	t.Run("削除すると無効化する", func(t *testing.T) {
+		if err := q.DeleteArticle(ctx, database.DeleteArticleParams{ArticleID: articleID, UserID: userID}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := q.GetArticle(ctx, params); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expect: %v, Got: %v", sql.ErrNoRows, err)
+		}
+	})
+}
+
+func TestQuerier_GetArticle_singleflight(t *testing.T) {
+	ctx := context.Background()
+	q, db, userID, articleID := setup(t)
+	db.block = make(chan struct{})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := q.GetArticle(ctx, database.GetArticleParams{ArticleID: articleID, UserID: userID})
+			errs <- err
+		}()
+	}
+
+	// 全員が取得を待ち始めるまで待つ
+	for db.articles.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(db.block)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("err should be nil: %v", err)
+		}
+	}
+	if n := db.articles.Load(); n != 1 {
+		t.Errorf("Expect: %v, Got: %v", 1, n)
+	}
+}
+
+func TestQuerier_GetArticle_cancel(t *testing.T) {
+	q, db, userID, articleID := setup(t)
+	db.block = make(chan struct{})
+	params := database.GetArticleParams{ArticleID: articleID, UserID: userID}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := q.GetArticle(ctx, params)
+		first <- err
+	}()
+	for db.articles.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	second := make(chan error)
+	go func() {
+		_, err := q.GetArticle(context.Background(), params)
+		second <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// 最初の呼び出し元がキャンセルしても、同じ記事を待っている呼び出し元は結果を受け取れる
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expect: %v, Got: %v", context.Canceled, err)
+	}
+
+	close(db.block)
+	if err := <-second; err != nil {
+		t.Errorf("err should be nil: %v", err)
+	}
+	if n := db.articles.Load(); n != 1 {
+		t.Errorf("Expect: %v, Got: %v", 1, n)
+	}
+}
+
+func TestQuerier_GetSession(t *testing.T) {
+	ctx := context.Background()
+	q, db, userID, _ := setup(t)
+
+	if err := q.CreateSession(ctx, database.CreateSessionParams{AccessToken: "token", UserID: userID, ExpiredAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		resp, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "token"})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if resp.ID != userID {
+			t.Errorf("Expect: %v, Got: %v", userID, resp.ID)
+		}
+	}
+	if n := db.sessions.Load(); n != 1 {
+		t.Errorf("Expect: %v, Got: %v", 1, n)
+	}
+
+	t.Run("見つからない結果は保存しない", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if _, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "unknown"}); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("Expect: %v, Got: %v", sql.ErrNoRows, err)
+			}
+		}
+		if n := db.sessions.Load(); n != 3 {
+			t.Errorf("Expect: %v, Got: %v", 3, n)
+		}
+	})
+
+	t.Run("有効期限より長く保存しない", func(t *testing.T) {
+		err := q.CreateSession(ctx, database.CreateSessionParams{AccessToken: "short", UserID: userID, ExpiredAt: time.Now().Add(50 * time.Millisecond)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "short"}); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		if _, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "short"}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expired session should not be found: %v", err)
+		}
+	})
+
+	t.Run("セッションを削除すると無効化する", func(t *testing.T) {
+		if err := q.DeleteSession(ctx, database.DeleteSessionParams{AccessToken: "token"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "token"}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("revoked session should not be found: %v", err)
+		}
+	})
+}
+
+func TestQuerier_DeleteSession_replica(t *testing.T) {
+	ctx := context.Background()
+	primary, replica := dbtest.OpenSQLite(t), dbtest.OpenSQLite(t)
+
+	// 削除がまだ届いていないレプリカとして、プライマリと同じユーザーとセッションを作成しておく
+	for _, db := range []*bun.DB{primary, replica} {
+		q := database.NewQuery(db)
+		user, err := q.SignUp(ctx, database.SignUpParams{Email: "test@example.com", Password: "hash"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := q.CreateSession(ctx, database.CreateSessionParams{AccessToken: "token", UserID: user.UserID, ExpiredAt: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replicas := database.NewReplicaSet([]*bun.DB{replica}, time.Minute)
+	q := NewQuerier(database.NewQuery(primary, database.WithReplicas(replicas)), NewLRU(100))
+
+	if _, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "token"}); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if err := q.DeleteSession(ctx, database.DeleteSessionParams{AccessToken: "token"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "token"}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("revoked session should not be found: %v", err)
+		}
+	}
+}
+
+func TestQuerier_WithTx(t *testing.T) {
+	ctx := context.Background()
+	q, _, userID, articleID := setup(t)
+	params := database.GetArticleParams{ArticleID: articleID, UserID: userID}
+
+	if _, err := q.GetArticle(ctx, params); err != nil {
+		t.Fatal(err)
+	}
+
+	err := q.WithTx(ctx, func(tx database.Querier) error {
+		if err := tx.UpdateArticle(ctx, database.UpdateArticleParams{ArticleID: articleID, UserID: userID, Title: "updated", Text: "text"}); err != nil {
+			return err
+		}
+
+		// トランザクションの中ではキャッシュを使わない
+		resp, err := tx.GetArticle(ctx, params)
+		if err != nil {
+			return err
+		}
+		if resp.Article.Title != "updated" {
+			t.Errorf("Expect: %v, Got: %v", "updated", resp.Article.Title)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	resp, err := q.GetArticle(ctx, params)
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if resp.Article.Title != "updated" {
+		t.Errorf("cache should be invalidated after commit: %v", resp.Article.Title)
+	}
+}
@@ -0,0 +1,205 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	cfg "sample-grpc-server/config"
+	"sample-grpc-server/logger"
+	"sample-grpc-server/secret"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/uptrace/bun/driver/pgdriver"
+	"golang.org/x/xerrors"
+	"modernc.org/sqlite"
+)
+
+type Credentials struct {
+	User     string
+	Password string
+}
+
+// Connector は新しい接続を張るたびに最新の認証情報を使う。
+// 認証情報は設定の値をもとに、シークレットに値があればそちらで上書きする
+type Connector struct {
+	config  *cfg.Config
+	secrets secret.Provider
+
+	creds atomic.Pointer[Credentials]
+}
+
+var _ driver.Connector = (*Connector)(nil)
+
+func NewConnector(ctx context.Context, c *cfg.Config, secrets secret.Provider) (*Connector, error) {
+	conn := &Connector{
+		config:  c,
+		secrets: secrets,
+	}
+
+	if _, err := conn.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Refresh はシークレットから認証情報を取得し直し、変わった場合はtrueを返す
+func (c *Connector) Refresh(ctx context.Context) (bool, error) {
+	creds := Credentials{User: c.config.GetDBUser(), Password: c.config.GetDBPassword()}
+
+	if c.secrets != nil {
+		for name, dst := range map[string]*string{
+			secret.DBUser:     &creds.User,
+			secret.DBPassword: &creds.Password,
+		} {
+			v, err := c.secrets.Get(ctx, name)
+			if errors.Is(err, secret.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return false, xerrors.Errorf("failed to get %s: %v", name, err)
+			}
+			*dst = v
+		}
+	}
+
+	old := c.creds.Swap(&creds)
+
+	return old != nil && *old != creds, nil
+}
+
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.connect(ctx, c.config.GetDBAddr())
+}
+
+// Replica はaddrのレプリカに同じ認証情報で接続するConnectorを返す
+func (c *Connector) Replica(addr string) driver.Connector {
+	return &replicaConnector{Connector: c, addr: addr}
+}
+
+type replicaConnector struct {
+	*Connector
+	addr string
+}
+
+func (c *replicaConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.connect(ctx, c.addr)
+}
+
+func (c *Connector) connect(ctx context.Context, addr string) (driver.Conn, error) {
+	switch c.config.GetDBDriver() {
+	case cfg.DBDriverPostgres:
+		return c.connectPostgres(ctx, addr)
+	case cfg.DBDriverSQLite:
+		return c.connectSQLite()
+	default:
+		return c.connectMySQL(ctx, addr)
+	}
+}
+
+func (c *Connector) connectMySQL(ctx context.Context, addr string) (driver.Conn, error) {
+	creds := c.creds.Load()
+
+	mc := mysql.NewConfig()
+	mc.User = creds.User
+	mc.Passwd = creds.Password
+	mc.Net = "tcp"
+	mc.Addr = addr
+	mc.DBName = c.config.GetDBName()
+	mc.ParseTime = true
+	// TIMESTAMP型の列はセッションのタイムゾーンとの間で変換される。
+	// 夏時間のあるタイムゾーンは時差が変わるため、セッションもドライバーもUTCに揃え、db.timezoneへの変換はQueryで行う
+	mc.Loc = time.UTC
+	mc.Params = map[string]string{"time_zone": "'+00:00'"}
+
+	conn, err := mysql.NewConnector(mc)
+	if err != nil {
+		return nil, err
+	}
+
+	return conn.Connect(ctx)
+}
+
+func (c *Connector) connectPostgres(ctx context.Context, addr string) (driver.Conn, error) {
+	creds := c.creds.Load()
+
+	// MySQLと同じくTLSは使わない
+	conn := pgdriver.NewConnector(
+		pgdriver.WithAddr(addr),
+		pgdriver.WithInsecure(true),
+		pgdriver.WithUser(creds.User),
+		pgdriver.WithPassword(creds.Password),
+		pgdriver.WithDatabase(c.config.GetDBName()),
+		pgdriver.WithConnParams(map[string]interface{}{"TimeZone": c.config.GetDBLocation().String()}),
+	)
+
+	return conn.Connect(ctx)
+}
+
+// connectSQLite はdb.nameのファイルを開く。ファイルがなければ作成する。
+// SQLiteは外部キー制約が既定で無効なため有効にし、書き込みが重なった場合はロックが外れるまで待つ。
+// トランザクションは開始時に書き込みのロックを取り、読み込んだ後の書き込みでSQLITE_BUSYにならないようにする
+func (c *Connector) connectSQLite() (driver.Conn, error) {
+	return (&sqlite.Driver{}).Open(SQLiteDSN(c.config.GetDBName()))
+}
+
+// SQLiteDSN はpathのファイルをconnectSQLiteと同じ設定で開くためのDSNを返す
+func SQLiteDSN(path string) string {
+	return path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate"
+}
+
+func (c *Connector) Driver() driver.Driver {
+	switch c.config.GetDBDriver() {
+	case cfg.DBDriverPostgres:
+		return pgdriver.NewDriver()
+	case cfg.DBDriverSQLite:
+		return &sqlite.Driver{}
+	default:
+		return &mysql.MySQLDriver{}
+	}
+}
+
+// WatchCredentials はintervalごとに認証情報を取得し直す。
+// 変わっていればdbsのそれぞれで待機中の接続を閉じ、以降の接続は新しい認証情報で張られる。
+// dbsにはプライマリとレプリカのように同じConnectorから作った全ての接続プールを渡す。
+// 実行中のクエリが使っている接続はそのまま完了させる
+func WatchCredentials(ctx context.Context, dbs []*sql.DB, conn *Connector, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := conn.Refresh(ctx)
+		if err != nil {
+			logger.Errorf("failed to refresh database credentials: %v", err)
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		reconnected := true
+		for _, db := range dbs {
+			// 待機中の接続の上限を一時的に0にすると、待機中の接続が閉じられる
+			db.SetMaxIdleConns(0)
+			db.SetMaxIdleConns(conn.config.GetDBMaxIdleConns())
+
+			if err := db.PingContext(ctx); err != nil {
+				logger.Errorf("failed to connect with rotated database credentials: %v", err)
+				reconnected = false
+			}
+		}
+		if reconnected {
+			logger.Infof("reconnected with rotated database credentials")
+		}
+	}
+}
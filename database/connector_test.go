@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	cfg "sample-grpc-server/config"
+	"sample-grpc-server/secret"
+	mock_secret "sample-grpc-server/secret/mock"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestConnector_Refresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Setenv("DB_USER", "root")
+	t.Setenv("DB_PASSWORD", "password")
+	t.Setenv("DB_NAME", "app")
+	t.Setenv("DB_ADDR", "127.0.0.1:3306")
+	c, err := cfg.Load(cfg.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	secrets := mock_secret.NewMockProvider(ctrl)
+
+	// ユーザーはシークレットになく設定の値を使い、パスワードだけローテーションされる
+	secrets.EXPECT().Get(gomock.Any(), secret.DBUser).Return("", secret.ErrNotFound).Times(3)
+	gomock.InOrder(
+		secrets.EXPECT().Get(gomock.Any(), secret.DBPassword).Return("v1", nil).Times(2),
+		secrets.EXPECT().Get(gomock.Any(), secret.DBPassword).Return("v2", nil),
+	)
+
+	conn, err := NewConnector(ctx, c, secrets)
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if got := *conn.creds.Load(); got != (Credentials{User: "root", Password: "v1"}) {
+		t.Errorf("unexpected credentials: %+v", got)
+	}
+
+	if changed, err := conn.Refresh(ctx); err != nil || changed {
+		t.Errorf("Refresh() = %v, %v, want false", changed, err)
+	}
+
+	if changed, err := conn.Refresh(ctx); err != nil || !changed {
+		t.Errorf("Refresh() = %v, %v, want true", changed, err)
+	}
+	if got := conn.creds.Load().Password; got != "v2" {
+		t.Errorf("password should be rotated: %v", got)
+	}
+}
+
+func TestWatchCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Setenv("DB_USER", "root")
+	t.Setenv("DB_NAME", "app")
+	t.Setenv("DB_ADDR", "127.0.0.1:3306")
+	c, err := cfg.Load(cfg.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	secrets := mock_secret.NewMockProvider(ctrl)
+	secrets.EXPECT().Get(gomock.Any(), secret.DBUser).Return("", secret.ErrNotFound).AnyTimes()
+	gomock.InOrder(
+		secrets.EXPECT().Get(gomock.Any(), secret.DBPassword).Return("v1", nil),
+		secrets.EXPECT().Get(gomock.Any(), secret.DBPassword).Return("v2", nil).AnyTimes(),
+	)
+
+	conn, err := NewConnector(ctx, c, secrets)
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	// プライマリとレプリカの接続プールにそれぞれ待機中の接続を作っておく
+	var dbs []*sql.DB
+	for i := 0; i < 2; i++ {
+		db := sql.OpenDB(&fakeConnector{})
+		t.Cleanup(func() { db.Close() })
+		if err := db.Ping(); err != nil {
+			t.Fatal(err)
+		}
+		dbs = append(dbs, db)
+	}
+
+	go WatchCredentials(ctx, dbs, conn, 10*time.Millisecond)
+
+	// 認証情報が変わると全ての接続プールの待機中の接続が閉じられる
+	for i, db := range dbs {
+		deadline := time.Now().Add(time.Second)
+		for db.Stats().MaxIdleClosed == 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("idle connections of pool #%d should be closed", i)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
@@ -1,35 +1,70 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"os"
+	"database/sql/driver"
+	"math/rand"
 	"time"
 
 	cfg "sample-grpc-server/config"
+	"sample-grpc-server/logger"
 
-	"github.com/go-sql-driver/mysql"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/mysqldialect"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
 	"github.com/uptrace/bun/extra/bundebug"
+	"github.com/uptrace/bun/schema"
 	"golang.org/x/xerrors"
 )
 
-func NewDatabase() (*bun.DB, error) {
-	loc := time.FixedZone("Local", 9*60*60)
+const (
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 8 * time.Second
+	pingTimeout    = 2 * time.Second
+)
 
-	dns := FormatDNS(loc)
+// NewDatabase はconnを使って接続する。データベースが起動するのを待てるよう、
+// 接続できるまでdb.connect_timeoutの間は間隔を空けながら再試行する。
+// 認証情報のローテーションに追従するにはWatchCredentialsを併用する
+func NewDatabase(ctx context.Context, c *cfg.Config, conn *Connector) (*bun.DB, error) {
+	sqlDB := OpenDB(c, conn)
 
-	sqlDB, err := sql.Open("mysql", dns)
-	if err != nil {
-		return nil, xerrors.Errorf("failed to open connection: %v", err)
+	if err := WaitForConnection(ctx, sqlDB, c.GetDBConnectTimeout()); err != nil {
+		sqlDB.Close()
+		return nil, err
 	}
 
-	if err := sqlDB.Ping(); err != nil {
-		return nil, xerrors.Errorf("failed to verify connection: %v", err)
+	return newBunDB(c, sqlDB), nil
+}
+
+// NewReplicas は設定されたレプリカごとにbun.DBを作成する。
+// レプリカに接続できなくても起動は続け、ReplicaSetがプライマリに切り替える
+func NewReplicas(c *cfg.Config, conn *Connector) []*bun.DB {
+	dbs := make([]*bun.DB, 0, len(c.GetDBReplicas()))
+	for _, addr := range c.GetDBReplicas() {
+		dbs = append(dbs, newBunDB(c, OpenDB(c, conn.Replica(addr))))
 	}
 
-	db := bun.NewDB(sqlDB, mysqldialect.New())
-	if os.Getenv("ENV") == "development" {
+	return dbs
+}
+
+// NewDialect はdb.driverに対応するbunのダイアレクトを返す
+func NewDialect(driver string) schema.Dialect {
+	switch driver {
+	case cfg.DBDriverPostgres:
+		return pgdialect.New()
+	case cfg.DBDriverSQLite:
+		return sqlitedialect.New()
+	default:
+		return mysqlDialect{mysqldialect.New()}
+	}
+}
+
+func newBunDB(c *cfg.Config, sqlDB *sql.DB) *bun.DB {
+	db := bun.NewDB(sqlDB, NewDialect(c.GetDBDriver()))
+	if c.GetEnv() == "development" {
 		db.AddQueryHook(
 			bundebug.NewQueryHook(
 				bundebug.WithEnabled(true),
@@ -37,19 +72,68 @@ func NewDatabase() (*bun.DB, error) {
 		)
 	}
 
-	return db, nil
+	return db
+}
+
+// OpenDB は設定した接続プールでsql.DBを作成する。接続は実際に使うまで張られない
+func OpenDB(c *cfg.Config, conn driver.Connector) *sql.DB {
+	db := sql.OpenDB(conn)
+	db.SetMaxOpenConns(c.GetDBMaxOpenConns())
+	db.SetMaxIdleConns(c.GetDBMaxIdleConns())
+	db.SetConnMaxLifetime(c.GetDBConnMaxLifetime())
+	db.SetConnMaxIdleTime(c.GetDBConnMaxIdleTime())
+
+	return db
 }
 
-func FormatDNS(loc *time.Location) string {
-	c := mysql.Config{
-		User:      cfg.Cfg.GetDBUser(),
-		Passwd:    cfg.Cfg.GetDBPassword(),
-		Net:       "tcp",
-		Addr:      cfg.Cfg.GetDBAddr(),
-		DBName:    cfg.Cfg.GetDBName(),
-		Loc:       loc,
-		ParseTime: true,
+// WaitForConnection は接続できるまでtimeoutの間、間隔を倍にしながら再試行する。timeoutが0の場合は再試行しない
+func WaitForConnection(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	if timeout <= 0 {
+		if err := ping(ctx, db); err != nil {
+			return xerrors.Errorf("failed to verify connection: %v", err)
+		}
+		return nil
 	}
 
-	return c.FormatDSN()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		err := ping(ctx, db)
+		if err == nil {
+			return nil
+		}
+
+		// 待っている間にタイムアウトする場合は、最後のエラーを返す
+		wait := jitter(backoff)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return xerrors.Errorf("failed to verify connection after %d attempts: %v", attempt, err)
+		}
+
+		logger.Warnf("failed to connect to database (attempt %d), retrying in %s: %v", attempt, wait.Round(time.Millisecond), err)
+
+		select {
+		case <-ctx.Done():
+			return xerrors.Errorf("failed to verify connection after %d attempts: %v", attempt, err)
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func ping(ctx context.Context, db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	return db.PingContext(ctx)
+}
+
+// jitter は複数のインスタンスが同時に再試行しないよう、待ち時間を0.5倍から1.5倍の間でばらつかせる
+func jitter(d time.Duration) time.Duration {
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
 }
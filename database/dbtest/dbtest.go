@@ -0,0 +1,285 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/database/migrations"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
+	"github.com/uptrace/bun/migrate"
+	"golang.org/x/xerrors"
+)
+
+const startTimeout = 60 * time.Second
+
+// 外部キーの参照先より先に削除する順番に並べる
+var tables = []string{"thumbnails", "attachments", "articles", "sessions", "users"}
+
+// Target はテストに使うデータベースの接続先
+type Target struct {
+	Driver   string
+	Addr     string
+	User     string
+	Password string
+	Name     string
+}
+
+var (
+	target      *Target
+	migrateOnce sync.Once
+	errMigrate  error
+)
+
+// Run はテスト用のデータベースを用意してからmのテストを実行する。TestMainから呼ぶ。
+// TEST_DB_ADDRが指定されていればそのデータベース(TEST_DB_DRIVER, TEST_DB_USER, TEST_DB_PASSWORD, TEST_DB_NAME)を使う。
+// 指定がなくPATHにmysqldがあれば、一時ディレクトリにデータベースを作成して起動し、終了時に停止する。
+// どちらもなければ一時ディレクトリに作成したSQLiteのファイルを使う
+func Run(m *testing.M) int {
+	if addr := os.Getenv("TEST_DB_ADDR"); addr != "" {
+		target = &Target{
+			Driver:   getenv("TEST_DB_DRIVER", "mysql"),
+			Addr:     addr,
+			User:     getenv("TEST_DB_USER", "root"),
+			Password: os.Getenv("TEST_DB_PASSWORD"),
+			Name:     getenv("TEST_DB_NAME", "test"),
+		}
+		return m.Run()
+	}
+
+	dir, err := os.MkdirTemp("", "dbtest")
+	if err != nil {
+		log.Printf("failed to create temporary directory: %v", err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := exec.LookPath("mysqld"); err != nil {
+		target = &Target{Driver: "sqlite", Name: filepath.Join(dir, "test.db")}
+		return m.Run()
+	}
+
+	t, stop, err := startMySQL(dir)
+	if err != nil {
+		log.Printf("failed to start mysqld: %v", err)
+		return 1
+	}
+	defer stop()
+
+	target = t
+	return m.Run()
+}
+
+// Open はマイグレーションを適用し、全てのテーブルを空にしたデータベースに接続する。
+// テーブルを共有するため、Openを呼ぶテストは並列に実行しない
+func Open(t testing.TB) *bun.DB {
+	t.Helper()
+
+	if target == nil {
+		t.Skip("no database for integration tests: run the tests from TestMain with dbtest.Run")
+	}
+
+	db, err := open(target)
+	if err != nil {
+		t.Fatalf("failed to connect to %s %s: %v", target.Driver, target.Addr, err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+
+	migrateOnce.Do(func() { errMigrate = applyMigrations(ctx, db) })
+	if errMigrate != nil {
+		t.Fatalf("failed to apply migrations: %v", errMigrate)
+	}
+
+	for _, table := range tables {
+		if _, err := db.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			t.Fatalf("failed to clean %s: %v", table, err)
+		}
+	}
+
+	return db
+}
+
+// OpenSQLite は一時ディレクトリにマイグレーションを適用したSQLiteのデータベースを作成して接続する。
+// Openと違い呼ぶたびに別のデータベースになるため、プライマリとレプリカのように複数のデータベースを使うテストに使う
+func OpenSQLite(t testing.TB) *bun.DB {
+	t.Helper()
+
+	db, err := open(&Target{Driver: "sqlite", Name: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := applyMigrations(context.Background(), db); err != nil {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+
+	return db
+}
+
+func open(t *Target) (*bun.DB, error) {
+	switch t.Driver {
+	case "mysql":
+		mc := mysql.NewConfig()
+		mc.User = t.User
+		mc.Passwd = t.Password
+		mc.Net = "tcp"
+		mc.Addr = t.Addr
+		mc.DBName = t.Name
+		mc.Loc = time.UTC
+		mc.ParseTime = true
+		mc.Params = map[string]string{"time_zone": "'+00:00'"}
+
+		conn, err := mysql.NewConnector(mc)
+		if err != nil {
+			return nil, err
+		}
+		return bun.NewDB(sql.OpenDB(conn), database.NewDialect(t.Driver)), nil
+	case "postgres":
+		conn := pgdriver.NewConnector(
+			pgdriver.WithAddr(t.Addr),
+			pgdriver.WithInsecure(true),
+			pgdriver.WithUser(t.User),
+			pgdriver.WithPassword(t.Password),
+			pgdriver.WithDatabase(t.Name),
+			pgdriver.WithConnParams(map[string]interface{}{"TimeZone": "UTC"}),
+		)
+		return bun.NewDB(sql.OpenDB(conn), database.NewDialect(t.Driver)), nil
+	case "sqlite":
+		sqlDB, err := sql.Open("sqlite", database.SQLiteDSN(t.Name))
+		if err != nil {
+			return nil, err
+		}
+		return bun.NewDB(sqlDB, database.NewDialect(t.Driver)), nil
+	default:
+		return nil, fmt.Errorf("unsupported driver %q", t.Driver)
+	}
+}
+
+func applyMigrations(ctx context.Context, db *bun.DB) error {
+	ms, err := migrations.For(db.Dialect().Name())
+	if err != nil {
+		return err
+	}
+
+	m := migrate.NewMigrator(db, ms)
+	if err := m.Init(ctx); err != nil {
+		return err
+	}
+	if _, err := m.Migrate(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// startMySQL はdirにデータディレクトリを作成してmysqldを起動し、rootでパスワードなしに接続できるtestデータベースを作成する
+func startMySQL(dir string) (*Target, func(), error) {
+	datadir := filepath.Join(dir, "data")
+
+	args := []string{"--no-defaults", "--datadir=" + datadir}
+	if os.Geteuid() == 0 {
+		args = append(args, "--user=root")
+	}
+
+	if out, err := exec.Command("mysqld", append(args, "--initialize-insecure")...).CombinedOutput(); err != nil {
+		return nil, nil, xerrors.Errorf("failed to initialize data directory: %v: %s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cmd := exec.Command("mysqld", append(args,
+		"--bind-address=127.0.0.1",
+		fmt.Sprintf("--port=%d", port),
+		"--socket="+filepath.Join(dir, "mysqld.sock"),
+		"--pid-file="+filepath.Join(dir, "mysqld.pid"),
+		"--log-error="+filepath.Join(dir, "error.log"),
+		"--mysqlx=OFF",
+		"--skip-log-bin",
+	)...)
+	if err := cmd.Start(); err != nil {
+		return nil, nil, xerrors.Errorf("failed to start: %v", err)
+	}
+
+	stop := func() {
+		cmd.Process.Signal(os.Interrupt)
+		cmd.Wait()
+	}
+
+	t := &Target{Driver: "mysql", Addr: fmt.Sprintf("127.0.0.1:%d", port), User: "root", Name: "test"}
+
+	if err := createDatabase(t); err != nil {
+		stop()
+		return nil, nil, err
+	}
+
+	return t, stop, nil
+}
+
+// createDatabase はmysqldが接続を受け付けるまで待ってからt.Nameのデータベースを作成する
+func createDatabase(t *Target) error {
+	root := *t
+	root.Name = ""
+
+	db, err := open(&root)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+
+	for {
+		err := db.PingContext(ctx)
+		if err == nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return xerrors.Errorf("mysqld did not become ready: %v", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, "CREATE DATABASE IF NOT EXISTS "+t.Name); err != nil {
+		return xerrors.Errorf("failed to create database: %v", err)
+	}
+
+	return nil
+}
+
+func freePort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer ln.Close()
+
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+func getenv(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
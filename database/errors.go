@@ -0,0 +1,110 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/uptrace/bun/driver/pgdriver"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// ErrUniqueViolation と ErrForeignKeyViolation はドライバーを使わない実装が制約違反を表すのに使う
+var (
+	ErrUniqueViolation     = errors.New("unique constraint violation")
+	ErrForeignKeyViolation = errors.New("foreign key constraint violation")
+)
+
+type errorKind int
+
+const (
+	errUnknown errorKind = iota
+	errUniqueViolation
+	errForeignKeyViolation
+	errDeadlock
+	// errLockTimeout はロック待ちのタイムアウトやシリアライズできなかったことによる失敗を表す
+	errLockTimeout
+)
+
+// classify はドライバーごとのエラーコードをドライバーに依存しない種類に変換する
+func classify(err error) errorKind {
+	switch {
+	case errors.Is(err, ErrUniqueViolation):
+		return errUniqueViolation
+	case errors.Is(err, ErrForeignKeyViolation):
+		return errForeignKeyViolation
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062: // ER_DUP_ENTRY
+			return errUniqueViolation
+		case 1451, 1452: // ER_ROW_IS_REFERENCED_2, ER_NO_REFERENCED_ROW_2
+			return errForeignKeyViolation
+		case 1213: // ER_LOCK_DEADLOCK
+			return errDeadlock
+		case 1205: // ER_LOCK_WAIT_TIMEOUT
+			return errLockTimeout
+		}
+		return errUnknown
+	}
+
+	var pgErr pgdriver.Error
+	if errors.As(err, &pgErr) {
+		switch pgErr.Field('C') {
+		case "23505": // unique_violation
+			return errUniqueViolation
+		case "23503": // foreign_key_violation
+			return errForeignKeyViolation
+		case "40P01": // deadlock_detected
+			return errDeadlock
+		case "40001", "55P03": // serialization_failure, lock_not_available
+			return errLockTimeout
+		}
+		return errUnknown
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return errUniqueViolation
+		case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+			return errForeignKeyViolation
+		}
+		// SQLiteはデッドロックを検出せず、ロックを取れなかった場合はbusy_timeoutの後にSQLITE_BUSYを返す。
+		// 拡張コード(SQLITE_BUSY_SNAPSHOTなど)も下位8ビットは元のコードになる
+		switch sqliteErr.Code() & 0xff {
+		case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+			return errLockTimeout
+		}
+	}
+
+	return errUnknown
+}
+
+// IsUniqueViolation は一意制約に違反したエラーかを判定する
+func IsUniqueViolation(err error) bool {
+	return classify(err) == errUniqueViolation
+}
+
+// IsForeignKeyViolation は外部キー制約に違反したエラーかを判定する
+func IsForeignKeyViolation(err error) bool {
+	return classify(err) == errForeignKeyViolation
+}
+
+// IsDeadlock はデッドロックを検出してトランザクションがロールバックされたエラーかを判定する
+func IsDeadlock(err error) bool {
+	return classify(err) == errDeadlock
+}
+
+// isRetryable はトランザクションを再実行すれば成功しうるエラーかを判定する
+func isRetryable(err error) bool {
+	switch classify(err) {
+	case errDeadlock, errLockTimeout:
+		return true
+	default:
+		return false
+	}
+}
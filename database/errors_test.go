@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/xerrors"
+)
+
+func Test_classify(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expect errorKind
+	}{
+		{name: "MySQLの一意制約違反", err: xerrors.Errorf("failed to sign up: %w", &mysql.MySQLError{Number: 1062}), expect: errUniqueViolation},
+		{name: "MySQLの外部キー制約違反", err: &mysql.MySQLError{Number: 1452}, expect: errForeignKeyViolation},
+		{name: "MySQLのデッドロック", err: &mysql.MySQLError{Number: 1213}, expect: errDeadlock},
+		{name: "MySQLのロック待ちのタイムアウト", err: &mysql.MySQLError{Number: 1205}, expect: errLockTimeout},
+		{name: "MySQLのその他のエラー", err: &mysql.MySQLError{Number: 1146}, expect: errUnknown},
+		{name: "ドライバーに依存しない一意制約違反", err: xerrors.Errorf("failed to sign up: %w", ErrUniqueViolation), expect: errUniqueViolation},
+		{name: "データベース以外のエラー", err: errors.New("some error"), expect: errUnknown},
+		{name: "nil", err: nil, expect: errUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classify(tt.err); got != tt.expect {
+				t.Errorf("Expect: %v, Got: %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func Test_classify_sqlite(t *testing.T) {
+	// sqlite.Errorは外部から作れないため、メモリ上のデータベースで実際に制約違反を起こす
+	db, err := sql.Open("sqlite", SQLiteDSN(":memory:"))
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, `CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT UNIQUE);
+CREATE TABLE articles (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users (id))`); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO users (id, email) VALUES (1, 'test@example.com')"); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		expect errorKind
+	}{
+		{name: "一意制約違反", query: "INSERT INTO users (id, email) VALUES (2, 'test@example.com')", expect: errUniqueViolation},
+		{name: "主キーの重複", query: "INSERT INTO users (id, email) VALUES (1, 'other@example.com')", expect: errUniqueViolation},
+		{name: "外部キー制約違反", query: "INSERT INTO articles (user_id) VALUES (100)", expect: errForeignKeyViolation},
+		{name: "その他のエラー", query: "INSERT INTO unknown (id) VALUES (1)", expect: errUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.ExecContext(ctx, tt.query)
+			if got := classify(xerrors.Errorf("failed to insert: %w", err)); got != tt.expect {
+				t.Errorf("Expect: %v, Got: %v (%v)", tt.expect, got, err)
+			}
+		})
+	}
+}
+
+func Test_isRetryable(t *testing.T) {
+	if !isRetryable(xerrors.Errorf("failed to create article: %w", &mysql.MySQLError{Number: 1213})) {
+		t.Error("deadlock should be retryable")
+	}
+	if isRetryable(&mysql.MySQLError{Number: 1062}) {
+		t.Error("unique violation should not be retryable")
+	}
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"sample-grpc-server/logger"
+)
+
+// HealthChecker は定期的にデータベースに接続できるかを確認し、状態が変わったら通知する
+type HealthChecker struct {
+	db       *sql.DB
+	interval time.Duration
+
+	mu        sync.RWMutex
+	healthy   bool
+	listeners []func(healthy bool)
+}
+
+// NewHealthChecker は起動時に接続を確認済みの前提で、正常な状態から始める
+func NewHealthChecker(db *sql.DB, interval time.Duration) *HealthChecker {
+	return &HealthChecker{
+		db:       db,
+		interval: interval,
+		healthy:  true,
+	}
+}
+
+func (h *HealthChecker) Healthy() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.healthy
+}
+
+// OnChange は正常と異常が切り替わるたびにfnを呼ぶ
+func (h *HealthChecker) OnChange(fn func(healthy bool)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.listeners = append(h.listeners, fn)
+}
+
+// Run はctxがキャンセルされるまでintervalごとに確認する
+func (h *HealthChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.Check(ctx)
+		}
+	}
+}
+
+// Check は一度だけ確認し、接続プールの状態をログに出す
+func (h *HealthChecker) Check(ctx context.Context) {
+	err := ping(ctx, h.db)
+	if ctx.Err() != nil {
+		return
+	}
+
+	s := h.db.Stats()
+	logger.Debugf("database pool: open=%d in_use=%d idle=%d wait_count=%d wait_duration=%s max_idle_closed=%d max_lifetime_closed=%d",
+		s.OpenConnections, s.InUse, s.Idle, s.WaitCount, s.WaitDuration, s.MaxIdleClosed, s.MaxLifetimeClosed)
+
+	healthy := err == nil
+
+	h.mu.Lock()
+	changed := h.healthy != healthy
+	h.healthy = healthy
+	listeners := h.listeners
+	h.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if healthy {
+		logger.Infof("database is reachable again")
+	} else {
+		logger.Errorf("database is unreachable: %v", err)
+	}
+
+	for _, fn := range listeners {
+		fn(healthy)
+	}
+}
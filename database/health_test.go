@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeConnector はPingの成否を切り替えられる接続を返す
+type fakeConnector struct {
+	down  atomic.Bool
+	fails atomic.Int32
+}
+
+func (c *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if c.down.Load() {
+		return nil, errors.New("connection refused")
+	}
+	if c.fails.Load() > 0 {
+		c.fails.Add(-1)
+		return nil, errors.New("connection refused")
+	}
+	return fakeConn{}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver {
+	return nil
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not implemented") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not implemented") }
+
+func TestWaitForConnection(t *testing.T) {
+	t.Run("接続できるまで再試行する", func(t *testing.T) {
+		conn := &fakeConnector{}
+		conn.fails.Store(2)
+		db := sql.OpenDB(conn)
+		defer db.Close()
+
+		if err := WaitForConnection(context.Background(), db, 10*time.Second); err != nil {
+			t.Errorf("err should be nil: %v", err)
+		}
+	})
+
+	t.Run("タイムアウト", func(t *testing.T) {
+		conn := &fakeConnector{}
+		conn.down.Store(true)
+		db := sql.OpenDB(conn)
+		defer db.Close()
+
+		if err := WaitForConnection(context.Background(), db, 100*time.Millisecond); err == nil {
+			t.Error("err should not be nil")
+		}
+	})
+}
+
+func TestHealthChecker_Check(t *testing.T) {
+	conn := &fakeConnector{}
+	db := sql.OpenDB(conn)
+	defer db.Close()
+	// 接続を使い回さず、毎回Connectを呼ばせる
+	db.SetMaxIdleConns(0)
+
+	h := NewHealthChecker(db, time.Second)
+
+	var changes []bool
+	h.OnChange(func(healthy bool) { changes = append(changes, healthy) })
+
+	ctx := context.Background()
+
+	h.Check(ctx)
+	conn.down.Store(true)
+	h.Check(ctx)
+	h.Check(ctx)
+	if h.Healthy() {
+		t.Error("should be unhealthy")
+	}
+
+	conn.down.Store(false)
+	h.Check(ctx)
+	if !h.Healthy() {
+		t.Error("should be healthy")
+	}
+
+	if len(changes) != 2 || changes[0] || !changes[1] {
+		t.Errorf("listener should be called only on changes: %v", changes)
+	}
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"sample-grpc-server/logger"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"google.golang.org/grpc"
+)
+
+var (
+	_ bun.QueryHook        = (*QueryHook)(nil)
+	_ prometheus.Collector = (*QueryHook)(nil)
+)
+
+// QueryHook はクエリの種類ごとに実行時間とエラーの件数を記録し、時間のかかったクエリをログに出す。
+// 記録した値はprometheus.Collectorとして登録して取得する
+type QueryHook struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+
+	slowThreshold time.Duration
+	logf          func(format string, args ...interface{})
+}
+
+type QueryHookOption func(*QueryHook)
+
+// WithSlowQueryThreshold はthreshold以上かかったクエリをログに出す。0の場合は出さない
+func WithSlowQueryThreshold(threshold time.Duration) QueryHookOption {
+	return func(h *QueryHook) {
+		h.slowThreshold = threshold
+	}
+}
+
+func NewQueryHook(opts ...QueryHookOption) *QueryHook {
+	labels := []string{"operation", "grpc_method"}
+
+	h := &QueryHook{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Duration of database queries.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "db_query_errors_total",
+			Help: "Number of failed database queries.",
+		}, labels),
+		logf: logger.Warnf,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+func (h *QueryHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *QueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	elapsed := time.Since(event.StartTime)
+
+	method, ok := grpc.Method(ctx)
+	if !ok {
+		method = "none"
+	}
+	operation := event.Operation()
+
+	h.duration.WithLabelValues(operation, method).Observe(elapsed.Seconds())
+
+	// 見つからないことは失敗として数えない
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		h.errors.WithLabelValues(operation, method).Inc()
+	}
+
+	if h.slowThreshold > 0 && elapsed >= h.slowThreshold {
+		mysql := event.DB != nil && event.DB.Dialect().Name() == dialect.MySQL
+		h.logf("slow query (%s, %s): %s", elapsed.Round(time.Millisecond), method, redact(event.Query, mysql))
+	}
+}
+
+func (h *QueryHook) Describe(ch chan<- *prometheus.Desc) {
+	h.duration.Describe(ch)
+	h.errors.Describe(ch)
+}
+
+func (h *QueryHook) Collect(ch chan<- prometheus.Metric) {
+	h.duration.Collect(ch)
+	h.errors.Collect(ch)
+}
+
+// redact はクエリに埋め込まれた文字列と数値を?に置き換える。識別子やキーワードはそのまま残す。
+// MySQLでは文字列の中のバックスラッシュもエスケープとして扱う
+func redact(query string, backslashEscapes bool) string {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		switch {
+		case c == '\'':
+			i = skipString(query, i+1, backslashEscapes)
+			b.WriteByte('?')
+		case c == '"' || c == '`':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 2
+		case isDigit(c) && (i == 0 || !isIdentByte(query[i-1])):
+			for i < len(query) && (isDigit(query[i]) || query[i] == '.') {
+				i++
+			}
+			b.WriteByte('?')
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// skipString は文字列リテラルの開始位置の次のiから、閉じる'の次の位置を返す。閉じていない場合は末尾まで読み飛ばす
+func skipString(query string, i int, backslashEscapes bool) int {
+	for i < len(query) {
+		switch {
+		case backslashEscapes && query[i] == '\\':
+			i += 2
+		case query[i] == '\'':
+			if i+1 < len(query) && query[i+1] == '\'' {
+				i += 2
+				continue
+			}
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return len(query)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
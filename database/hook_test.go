@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/uptrace/bun"
+	"google.golang.org/grpc"
+)
+
+func Test_redact(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		mysql  bool
+		expect string
+	}{
+		{
+			name:   "文字列と数値",
+			query:  "SELECT `a`.`id` FROM `articles` AS `a` WHERE (user_id = 12) AND (title = 'secret') LIMIT 1",
+			mysql:  true,
+			expect: "SELECT `a`.`id` FROM `articles` AS `a` WHERE (user_id = ?) AND (title = ?) LIMIT ?",
+		},
+		{
+			name:   "エスケープされた引用符",
+			query:  `INSERT INTO "users" ("email", "password") VALUES ('it''s', 'a\', 'b')`,
+			expect: `INSERT INTO "users" ("email", "password") VALUES (?, ?, ?)`,
+		},
+		{
+			name:   "MySQLのバックスラッシュ",
+			query:  `UPDATE articles SET text = 'a\\', title = 'b\'c' WHERE id = 3`,
+			mysql:  true,
+			expect: `UPDATE articles SET text = ?, title = ? WHERE id = ?`,
+		},
+		{
+			name:   "数字を含む識別子と小数",
+			query:  `SELECT t1.id FROM "table2" AS t1 WHERE score > -1.5`,
+			expect: `SELECT t1.id FROM "table2" AS t1 WHERE score > -?`,
+		},
+		{
+			name:   "閉じていない文字列",
+			query:  `SELECT 'secret`,
+			expect: `SELECT ?`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redact(tt.query, tt.mysql); got != tt.expect {
+				t.Errorf("Expect: %v, Got: %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+type fakeTransportStream struct {
+	grpc.ServerTransportStream
+	method string
+}
+
+func (s fakeTransportStream) Method() string {
+	return s.method
+}
+
+func TestQueryHook(t *testing.T) {
+	var logs []string
+	h := NewQueryHook(WithSlowQueryThreshold(100 * time.Millisecond))
+	h.logf = func(format string, args ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}
+
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), fakeTransportStream{method: "/backend.BackendService/GetArticle"})
+
+	run := func(ctx context.Context, query string, elapsed time.Duration, err error) {
+		h.AfterQuery(ctx, &bun.QueryEvent{Query: query, StartTime: time.Now().Add(-elapsed), Err: err})
+	}
+
+	run(ctx, "SELECT id FROM articles WHERE id = 1", time.Millisecond, nil)
+	run(ctx, "SELECT id FROM articles WHERE id = 2", time.Millisecond, sql.ErrNoRows)
+	run(ctx, "UPDATE articles SET title = 'secret' WHERE id = 1", time.Second, errors.New("deadlock"))
+	run(context.Background(), "DELETE FROM sessions", time.Millisecond, nil)
+
+	t.Run("実行時間", func(t *testing.T) {
+		if n := testutil.CollectAndCount(h, "db_query_duration_seconds"); n != 3 {
+			t.Errorf("Expect: %v, Got: %v", 3, n)
+		}
+	})
+
+	t.Run("エラーの件数", func(t *testing.T) {
+		if got := testutil.ToFloat64(h.errors.WithLabelValues("UPDATE", "/backend.BackendService/GetArticle")); got != 1 {
+			t.Errorf("Expect: %v, Got: %v", 1, got)
+		}
+		// 見つからなかっただけのクエリは数えない
+		if got := testutil.ToFloat64(h.errors.WithLabelValues("SELECT", "/backend.BackendService/GetArticle")); got != 0 {
+			t.Errorf("Expect: %v, Got: %v", 0, got)
+		}
+	})
+
+	t.Run("遅いクエリのログ", func(t *testing.T) {
+		if len(logs) != 1 {
+			t.Fatalf("Expect: %v, Got: %v", 1, logs)
+		}
+		if !strings.Contains(logs[0], "/backend.BackendService/GetArticle") || !strings.Contains(logs[0], "SET title = ? WHERE id = ?") {
+			t.Errorf("unexpected log: %v", logs[0])
+		}
+		if strings.Contains(logs[0], "secret") {
+			t.Errorf("arguments should be redacted: %v", logs[0])
+		}
+	})
+}
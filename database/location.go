@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql"
+	"reflect"
+	"time"
+
+	"github.com/uptrace/bun/dialect/mysqldialect"
+)
+
+// mysqlDialect は日時をUTCに変換してクエリに埋め込む。
+// mysqldialectは日時をそのタイムゾーンのまま時差を付けずに埋め込むため、UTCに揃えた接続ではずれる
+type mysqlDialect struct {
+	*mysqldialect.Dialect
+}
+
+func (d mysqlDialect) AppendTime(b []byte, tm time.Time) []byte {
+	return d.Dialect.AppendTime(b, tm.UTC())
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+)
+
+// localize はvに含まれる日時をlocに変換する。
+// 夏時間のあるタイムゾーンでも正しく扱えるようMySQLとはUTCでやり取りし、設定したタイムゾーンにはGo側で変換する
+func localize(v reflect.Value, loc *time.Location) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			localize(v.Elem(), loc)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			localize(v.Index(i), loc)
+		}
+	case reflect.Struct:
+		switch v.Type() {
+		case timeType:
+			if t := v.Interface().(time.Time); !t.IsZero() && v.CanSet() {
+				v.Set(reflect.ValueOf(t.In(loc)))
+			}
+		case nullTimeType:
+			if t := v.Interface().(sql.NullTime); t.Valid && v.CanSet() {
+				t.Time = t.Time.In(loc)
+				v.Set(reflect.ValueOf(t))
+			}
+		default:
+			for i := 0; i < v.NumField(); i++ {
+				if v.Type().Field(i).IsExported() {
+					localize(v.Field(i), loc)
+				}
+			}
+		}
+	}
+}
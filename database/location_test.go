@@ -0,0 +1,67 @@
+package database
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"sample-grpc-server/database/model"
+
+	"github.com/uptrace/bun/dialect/mysqldialect"
+)
+
+func Test_mysqlDialect_AppendTime(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	tests := []struct {
+		name   string
+		tm     time.Time
+		expect string
+	}{
+		{name: "夏時間", tm: time.Date(2026, 7, 1, 8, 0, 0, 0, newYork), expect: "'2026-07-01 12:00:00'"},
+		{name: "標準時", tm: time.Date(2026, 1, 1, 7, 0, 0, 0, newYork), expect: "'2026-01-01 12:00:00'"},
+	}
+
+	d := mysqlDialect{mysqldialect.New()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(d.AppendTime(nil, tt.tm)); got != tt.expect {
+				t.Errorf("Expect: %v, Got: %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func Test_localize(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	summer := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	winter := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	articles := []model.Article{{CreatedAt: summer, UpdatedAt: winter, DeletedAt: sql.NullTime{Time: winter, Valid: true}}}
+	attachment := &model.Attachment{CreatedAt: summer, Thumbnails: []*model.Thumbnail{{CreatedAt: winter}}}
+
+	localize(reflect.ValueOf(articles), newYork)
+	localize(reflect.ValueOf(attachment), newYork)
+
+	tests := []struct {
+		name   string
+		got    time.Time
+		expect string
+	}{
+		{name: "夏時間", got: articles[0].CreatedAt, expect: "2026-07-01T08:00:00-04:00"},
+		{name: "標準時", got: articles[0].UpdatedAt, expect: "2026-01-01T07:00:00-05:00"},
+		{name: "NULLを許す列", got: articles[0].DeletedAt.Time, expect: "2026-01-01T07:00:00-05:00"},
+		{name: "ポインタ", got: attachment.CreatedAt, expect: "2026-07-01T08:00:00-04:00"},
+		{name: "関連するモデル", got: attachment.Thumbnails[0].CreatedAt, expect: "2026-01-01T07:00:00-05:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.got.Format(time.RFC3339); got != tt.expect {
+				t.Errorf("Expect: %v, Got: %v", tt.expect, got)
+			}
+		})
+	}
+}
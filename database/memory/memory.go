@@ -0,0 +1,682 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/database/model"
+
+	"golang.org/x/xerrors"
+)
+
+// Querier はデータをメモリに保持するdatabase.Querierの実装。
+// 一意制約や外部キー、セッションの有効期限、deleted_atによる論理削除はdatabase.Queryと同じように扱う。
+// プロセスを終了するとデータは失われるため、テストやデモでの利用を想定している
+type Querier struct {
+	// mu はトランザクションの中ではnilになり、外側のWithTxが取ったロックを使う
+	mu    *sync.RWMutex
+	state *state
+}
+
+var _ database.Querier = (*Querier)(nil)
+
+func NewQuerier() *Querier {
+	return &Querier{
+		mu:    &sync.RWMutex{},
+		state: newState(),
+	}
+}
+
+type state struct {
+	users       map[int64]model.User
+	sessions    map[string]model.Session
+	articles    map[int64]model.Article
+	attachments map[int64]model.Attachment
+	thumbnails  map[int64]model.Thumbnail
+
+	lastUserID       int64
+	lastArticleID    int64
+	lastAttachmentID int64
+	lastThumbnailID  int64
+}
+
+func newState() *state {
+	return &state{
+		users:       map[int64]model.User{},
+		sessions:    map[string]model.Session{},
+		articles:    map[int64]model.Article{},
+		attachments: map[int64]model.Attachment{},
+		thumbnails:  map[int64]model.Thumbnail{},
+	}
+}
+
+// clone はトランザクション用にstateを複製する。値はすべて構造体で持っているためマップの複製だけで足りる
+func (s *state) clone() *state {
+	c := *s
+	c.users = cloneMap(s.users)
+	c.sessions = cloneMap(s.sessions)
+	c.articles = cloneMap(s.articles)
+	c.attachments = cloneMap(s.attachments)
+	c.thumbnails = cloneMap(s.thumbnails)
+
+	return &c
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func (q *Querier) read(fn func(s *state) error) error {
+	if q.mu != nil {
+		q.mu.RLock()
+		defer q.mu.RUnlock()
+	}
+	return fn(q.state)
+}
+
+func (q *Querier) write(fn func(s *state) error) error {
+	if q.mu != nil {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+	}
+	return fn(q.state)
+}
+
+// WithTx はfnを複製したデータに対して実行し、fnが成功した場合だけ反映する。
+// 実行中は他の読み書きを待たせるため、トランザクション同士は直列に実行される
+func (q *Querier) WithTx(ctx context.Context, fn func(database.Querier) error) error {
+	return q.write(func(s *state) error {
+		tx := &Querier{state: s.clone()}
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		*s = *tx.state
+		return nil
+	})
+}
+
+func (q *Querier) SignUp(ctx context.Context, p database.SignUpParams) (*database.SignUpResult, error) {
+	var id int64
+
+	err := q.write(func(s *state) error {
+		// 一意制約は削除済みのユーザーも対象になる
+		for _, u := range s.users {
+			if u.Email == p.Email {
+				return database.ErrUniqueViolation
+			}
+		}
+
+		now := time.Now()
+		s.lastUserID++
+		id = s.lastUserID
+		s.users[id] = model.User{
+			ID:        id,
+			Email:     p.Email,
+			Password:  p.Password,
+			Timezone:  "UTC",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("新規ユーザー登録: %w", err)
+	}
+
+	return &database.SignUpResult{UserID: id}, nil
+}
+
+func (q *Querier) Login(ctx context.Context, p database.LoginParams) (*database.LoginResult, error) {
+	var result *database.LoginResult
+
+	err := q.read(func(s *state) error {
+		for _, u := range s.users {
+			if u.Email == p.Email && !u.DeletedAt.Valid {
+				result = &database.LoginResult{UserID: u.ID, Password: u.Password}
+				return nil
+			}
+		}
+		return sql.ErrNoRows
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("ユーザー取得: %w", err)
+	}
+
+	return result, nil
+}
+
+func (q *Querier) CreateSession(ctx context.Context, p database.CreateSessionParams) error {
+	err := q.write(func(s *state) error {
+		if _, ok := s.users[p.UserID]; !ok {
+			return database.ErrForeignKeyViolation
+		}
+		if _, ok := s.sessions[p.AccessToken]; ok {
+			return database.ErrUniqueViolation
+		}
+
+		s.sessions[p.AccessToken] = model.Session{
+			AccessToken: p.AccessToken,
+			UserID:      p.UserID,
+			CreatedAt:   time.Now(),
+			ExpiredAt:   p.ExpiredAt,
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+func (q *Querier) GetSession(ctx context.Context, p database.GetSessionParams) (*database.GetSessionResult, error) {
+	var session model.Session
+
+	err := q.read(func(s *state) error {
+		var ok bool
+		session, ok = s.sessions[p.AccessToken]
+		if !ok || !session.ExpiredAt.After(time.Now()) {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get session: %w", err)
+	}
+
+	return &database.GetSessionResult{ID: session.UserID, ExpiredAt: session.ExpiredAt}, nil
+}
+
+func (q *Querier) DeleteSession(ctx context.Context, p database.DeleteSessionParams) error {
+	return q.write(func(s *state) error {
+		delete(s.sessions, p.AccessToken)
+		return nil
+	})
+}
+
+func (q *Querier) GetUserTimezone(ctx context.Context, p database.GetUserTimezoneParams) (*database.GetUserTimezoneResult, error) {
+	var timezone string
+
+	err := q.read(func(s *state) error {
+		u, ok := s.users[p.UserID]
+		if !ok || u.DeletedAt.Valid {
+			return sql.ErrNoRows
+		}
+
+		timezone = u.Timezone
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get timezone: %w", err)
+	}
+
+	return &database.GetUserTimezoneResult{Timezone: timezone}, nil
+}
+
+func (q *Querier) UpdateUserTimezone(ctx context.Context, p database.UpdateUserTimezoneParams) error {
+	return q.write(func(s *state) error {
+		u, ok := s.users[p.UserID]
+		if !ok || u.DeletedAt.Valid {
+			return nil
+		}
+
+		u.Timezone = p.Timezone
+		u.UpdatedAt = time.Now()
+		s.users[u.ID] = u
+		return nil
+	})
+}
+
+func (q *Querier) CreateArticle(ctx context.Context, p database.CreateArticleParams) (*database.CreateArticleResult, error) {
+	var id int64
+
+	err := q.write(func(s *state) error {
+		if _, ok := s.users[p.UserID]; !ok {
+			return database.ErrForeignKeyViolation
+		}
+
+		id = s.insertArticle(model.Article{
+			UserID:      p.UserID,
+			Title:       p.Title,
+			Description: p.Description,
+			Text:        p.Text,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create article: %w", err)
+	}
+
+	return &database.CreateArticleResult{ArticleID: id}, nil
+}
+
+func (s *state) insertArticle(a model.Article) int64 {
+	now := time.Now()
+
+	s.lastArticleID++
+	a.ID = s.lastArticleID
+	a.CreatedAt = now
+	a.UpdatedAt = now
+	s.articles[a.ID] = a
+
+	return a.ID
+}
+
+func (q *Querier) GetArticles(ctx context.Context, p database.GetArticlesParams) (*database.GetArticlesResult, error) {
+	var articles []model.Article
+
+	q.read(func(s *state) error {
+		for _, a := range s.articles {
+			if a.UserID == p.UserID && !a.DeletedAt.Valid {
+				articles = append(articles, a)
+			}
+		}
+		return nil
+	})
+
+	// Queryと同じく、並び順のカラムが同じ記事はIDで順番を決める
+	sort.Slice(articles, func(i, j int) bool {
+		c := compareArticles(articles[i], articles[j], p.SortBy)
+		if c == 0 {
+			c = compare(articles[i].ID, articles[j].ID)
+		}
+		if p.Ascending {
+			return c < 0
+		}
+		return c > 0
+	})
+
+	return &database.GetArticlesResult{Articles: articles}, nil
+}
+
+func compareArticles(a, b model.Article, by database.ArticleSort) int {
+	switch by {
+	case database.ArticleSortUpdatedAt:
+		return compareTime(a.UpdatedAt, b.UpdatedAt)
+	case database.ArticleSortTitle:
+		return compare(a.Title, b.Title)
+	default:
+		return compareTime(a.CreatedAt, b.CreatedAt)
+	}
+}
+
+func compare[T int64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (q *Querier) GetArticle(ctx context.Context, p database.GetArticleParams) (*database.GetArticleResult, error) {
+	var article model.Article
+
+	err := q.read(func(s *state) error {
+		a, ok := s.articles[p.ArticleID]
+		if !ok || a.UserID != p.UserID || a.DeletedAt.Valid {
+			return sql.ErrNoRows
+		}
+
+		article = a
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("article not found: %w", err)
+	}
+
+	return &database.GetArticleResult{Article: article}, nil
+}
+
+// UpdateArticle はdatabase.Queryと同じく削除済みの記事も更新する
+func (q *Querier) UpdateArticle(ctx context.Context, p database.UpdateArticleParams) error {
+	return q.write(func(s *state) error {
+		a, ok := s.articles[p.ArticleID]
+		if !ok || a.UserID != p.UserID {
+			return nil
+		}
+
+		a.Title = p.Title
+		a.Description = p.Description
+		a.Text = p.Text
+		a.UpdatedAt = time.Now()
+		s.articles[a.ID] = a
+		return nil
+	})
+}
+
+func (q *Querier) UpdateArticleText(ctx context.Context, p database.UpdateArticleTextParams) error {
+	return q.write(func(s *state) error {
+		a, ok := s.articles[p.ArticleID]
+		if !ok || a.UserID != p.UserID {
+			return nil
+		}
+
+		a.Text = p.Text
+		a.UpdatedAt = time.Now()
+		s.articles[a.ID] = a
+		return nil
+	})
+}
+
+func (q *Querier) DeleteArticle(ctx context.Context, p database.DeleteArticleParams) error {
+	return q.write(func(s *state) error {
+		a, ok := s.articles[p.ArticleID]
+		if !ok || a.UserID != p.UserID {
+			return nil
+		}
+
+		a.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		s.articles[a.ID] = a
+		return nil
+	})
+}
+
+func (q *Querier) BatchCreateArticles(ctx context.Context, p database.BatchCreateArticlesParams) (*database.BatchCreateArticlesResult, error) {
+	if len(p.Articles) == 0 {
+		return &database.BatchCreateArticlesResult{}, nil
+	}
+
+	ids := make([]int64, 0, len(p.Articles))
+
+	err := q.write(func(s *state) error {
+		if _, ok := s.users[p.UserID]; !ok {
+			return database.ErrForeignKeyViolation
+		}
+
+		// 1件でも制約に違反すれば何も登録しないよう、先にすべて確認する
+		externalIDs := map[string]bool{}
+		for _, a := range s.articles {
+			if a.UserID == p.UserID && a.ExternalID.Valid {
+				externalIDs[a.ExternalID.String] = true
+			}
+		}
+		for _, a := range p.Articles {
+			if !a.ExternalID.Valid {
+				continue
+			}
+			if externalIDs[a.ExternalID.String] {
+				return database.ErrUniqueViolation
+			}
+			externalIDs[a.ExternalID.String] = true
+		}
+
+		for _, a := range p.Articles {
+			ids = append(ids, s.insertArticle(model.Article{
+				UserID:      p.UserID,
+				ExternalID:  a.ExternalID,
+				Title:       a.Title,
+				Description: a.Description,
+				Text:        a.Text,
+			}))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create articles: %w", err)
+	}
+
+	return &database.BatchCreateArticlesResult{ArticleIDs: ids}, nil
+}
+
+func (q *Querier) BatchGetArticles(ctx context.Context, p database.BatchGetArticlesParams) (*database.BatchGetArticlesResult, error) {
+	if len(p.ArticleIDs) == 0 {
+		return &database.BatchGetArticlesResult{}, nil
+	}
+
+	var articles []model.Article
+
+	q.read(func(s *state) error {
+		for _, id := range uniqueIDs(p.ArticleIDs) {
+			a, ok := s.articles[id]
+			if ok && a.UserID == p.UserID && !a.DeletedAt.Valid {
+				articles = append(articles, a)
+			}
+		}
+		return nil
+	})
+
+	return &database.BatchGetArticlesResult{Articles: articles}, nil
+}
+
+func (q *Querier) BatchDeleteArticles(ctx context.Context, p database.BatchDeleteArticlesParams) (*database.BatchDeleteArticlesResult, error) {
+	result := &database.BatchDeleteArticlesResult{}
+
+	if len(p.ArticleIDs) == 0 {
+		return result, nil
+	}
+
+	q.write(func(s *state) error {
+		var found []int64
+		exists := map[int64]bool{}
+		for _, id := range uniqueIDs(p.ArticleIDs) {
+			a, ok := s.articles[id]
+			if ok && a.UserID == p.UserID && !a.DeletedAt.Valid {
+				found = append(found, id)
+				exists[id] = true
+			}
+		}
+
+		for _, id := range p.ArticleIDs {
+			if !exists[id] {
+				result.MissingIDs = append(result.MissingIDs, id)
+			}
+		}
+
+		if len(found) == 0 || (p.AllOrNothing && len(result.MissingIDs) > 0) {
+			return nil
+		}
+
+		now := time.Now()
+		for _, id := range found {
+			a := s.articles[id]
+			a.DeletedAt = sql.NullTime{Time: now, Valid: true}
+			s.articles[id] = a
+		}
+
+		result.DeletedIDs = found
+		return nil
+	})
+
+	return result, nil
+}
+
+// GetArticleExternalIDs はdatabase.Queryと同じく削除済みの記事の外部IDも返す
+func (q *Querier) GetArticleExternalIDs(ctx context.Context, p database.GetArticleExternalIDsParams) (*database.GetArticleExternalIDsResult, error) {
+	if len(p.ExternalIDs) == 0 {
+		return &database.GetArticleExternalIDsResult{}, nil
+	}
+
+	want := make(map[string]bool, len(p.ExternalIDs))
+	for _, id := range p.ExternalIDs {
+		want[id] = true
+	}
+
+	var ids []string
+
+	q.read(func(s *state) error {
+		for _, a := range s.articles {
+			if a.UserID == p.UserID && a.ExternalID.Valid && want[a.ExternalID.String] {
+				ids = append(ids, a.ExternalID.String)
+			}
+		}
+		return nil
+	})
+
+	sort.Strings(ids)
+
+	return &database.GetArticleExternalIDsResult{ExternalIDs: ids}, nil
+}
+
+func (q *Querier) CreateAttachment(ctx context.Context, p database.CreateAttachmentParams) (*database.CreateAttachmentResult, error) {
+	attachment := model.Attachment{
+		ArticleID:   p.ArticleID,
+		UserID:      p.UserID,
+		Filename:    p.Filename,
+		ContentType: p.ContentType,
+		Size:        p.Size,
+		SHA256:      p.SHA256,
+		StorageKey:  p.StorageKey,
+		CreatedAt:   time.Now(),
+	}
+
+	err := q.write(func(s *state) error {
+		if _, ok := s.articles[p.ArticleID]; !ok {
+			return database.ErrForeignKeyViolation
+		}
+		if _, ok := s.users[p.UserID]; !ok {
+			return database.ErrForeignKeyViolation
+		}
+		for _, at := range s.attachments {
+			if at.StorageKey == p.StorageKey {
+				return database.ErrUniqueViolation
+			}
+		}
+
+		s.lastAttachmentID++
+		attachment.ID = s.lastAttachmentID
+		s.attachments[attachment.ID] = attachment
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create attachment: %w", err)
+	}
+
+	return &database.CreateAttachmentResult{AttachmentID: attachment.ID, CreatedAt: attachment.CreatedAt}, nil
+}
+
+func (q *Querier) GetAttachment(ctx context.Context, p database.GetAttachmentParams) (*database.GetAttachmentResult, error) {
+	var attachment model.Attachment
+
+	err := q.read(func(s *state) error {
+		at, ok := s.attachments[p.AttachmentID]
+		if !ok || at.UserID != p.UserID {
+			return sql.ErrNoRows
+		}
+		if a := s.articles[at.ArticleID]; a.DeletedAt.Valid {
+			return sql.ErrNoRows
+		}
+
+		attachment = s.withThumbnails(at)
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("attachment not found: %w", err)
+	}
+
+	return &database.GetAttachmentResult{Attachment: attachment}, nil
+}
+
+func (q *Querier) GetArticleAttachments(ctx context.Context, p database.GetArticleAttachmentsParams) (*database.GetArticleAttachmentsResult, error) {
+	if len(p.ArticleIDs) == 0 {
+		return &database.GetArticleAttachmentsResult{}, nil
+	}
+
+	articleIDs := make(map[int64]bool, len(p.ArticleIDs))
+	for _, id := range p.ArticleIDs {
+		articleIDs[id] = true
+	}
+
+	var attachments []model.Attachment
+
+	q.read(func(s *state) error {
+		for _, at := range s.attachments {
+			if articleIDs[at.ArticleID] && at.UserID == p.UserID {
+				attachments = append(attachments, s.withThumbnails(at))
+			}
+		}
+		return nil
+	})
+
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i].ID < attachments[j].ID
+	})
+
+	return &database.GetArticleAttachmentsResult{Attachments: attachments}, nil
+}
+
+// withThumbnails はサイズの小さい順にサムネイルを設定したatを返す
+func (s *state) withThumbnails(at model.Attachment) model.Attachment {
+	at.Thumbnails = nil
+	for _, th := range s.thumbnails {
+		if th.AttachmentID == at.ID {
+			th := th
+			at.Thumbnails = append(at.Thumbnails, &th)
+		}
+	}
+
+	sort.Slice(at.Thumbnails, func(i, j int) bool {
+		return at.Thumbnails[i].Size < at.Thumbnails[j].Size
+	})
+
+	return at
+}
+
+func (q *Querier) CreateThumbnail(ctx context.Context, p database.CreateThumbnailParams) error {
+	err := q.write(func(s *state) error {
+		if _, ok := s.attachments[p.AttachmentID]; !ok {
+			return database.ErrForeignKeyViolation
+		}
+		for _, th := range s.thumbnails {
+			if (th.AttachmentID == p.AttachmentID && th.Size == p.Size) || th.StorageKey == p.StorageKey {
+				return database.ErrUniqueViolation
+			}
+		}
+
+		s.lastThumbnailID++
+		s.thumbnails[s.lastThumbnailID] = model.Thumbnail{
+			ID:           s.lastThumbnailID,
+			AttachmentID: p.AttachmentID,
+			Size:         p.Size,
+			Width:        p.Width,
+			Height:       p.Height,
+			ContentType:  p.ContentType,
+			StorageKey:   p.StorageKey,
+			CreatedAt:    time.Now(),
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("failed to create thumbnail: %w", err)
+	}
+
+	return nil
+}
+
+// uniqueIDs は重複を除いたidsを昇順で返す
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	unique := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	sort.Slice(unique, func(i, j int) bool { return unique[i] < unique[j] })
+
+	return unique
+}
@@ -0,0 +1,306 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"sample-grpc-server/database"
+)
+
+func signUp(t *testing.T, q *Querier, email string) int64 {
+	t.Helper()
+
+	resp, err := q.SignUp(context.Background(), database.SignUpParams{Email: email, Password: "hash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.UserID
+}
+
+func TestQuerier_SignUp(t *testing.T) {
+	ctx := context.Background()
+	q := NewQuerier()
+
+	userID := signUp(t, q, "test@example.com")
+
+	t.Run("登録済みのメールアドレス", func(t *testing.T) {
+		_, err := q.SignUp(ctx, database.SignUpParams{Email: "test@example.com", Password: "hash"})
+		if !database.IsUniqueViolation(err) {
+			t.Errorf("err should be unique violation: %v", err)
+		}
+	})
+
+	t.Run("ログイン", func(t *testing.T) {
+		resp, err := q.Login(ctx, database.LoginParams{Email: "test@example.com"})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if resp.UserID != userID || resp.Password != "hash" {
+			t.Errorf("unexpected result: %+v", resp)
+		}
+
+		if _, err := q.Login(ctx, database.LoginParams{Email: "unknown@example.com"}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expect: %v, Got: %v", sql.ErrNoRows, err)
+		}
+	})
+
+	t.Run("タイムゾーン", func(t *testing.T) {
+		if err := q.UpdateUserTimezone(ctx, database.UpdateUserTimezoneParams{UserID: userID, Timezone: "Asia/Tokyo"}); err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := q.GetUserTimezone(ctx, database.GetUserTimezoneParams{UserID: userID})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if resp.Timezone != "Asia/Tokyo" {
+			t.Errorf("Expect: %v, Got: %v", "Asia/Tokyo", resp.Timezone)
+		}
+	})
+}
+
+func TestQuerier_Session(t *testing.T) {
+	ctx := context.Background()
+	q := NewQuerier()
+	userID := signUp(t, q, "test@example.com")
+
+	if err := q.CreateSession(ctx, database.CreateSessionParams{AccessToken: "valid", UserID: userID, ExpiredAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.CreateSession(ctx, database.CreateSessionParams{AccessToken: "expired", UserID: userID, ExpiredAt: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "valid"})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if resp.ID != userID {
+		t.Errorf("Expect: %v, Got: %v", userID, resp.ID)
+	}
+
+	if _, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "expired"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expired session should not be found: %v", err)
+	}
+
+	err = q.CreateSession(ctx, database.CreateSessionParams{AccessToken: "other", UserID: 100, ExpiredAt: time.Now().Add(time.Hour)})
+	if !database.IsForeignKeyViolation(err) {
+		t.Errorf("err should be foreign key violation: %v", err)
+	}
+}
+
+func TestQuerier_Article(t *testing.T) {
+	ctx := context.Background()
+	q := NewQuerier()
+	userID := signUp(t, q, "test@example.com")
+	otherID := signUp(t, q, "other@example.com")
+
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		resp, err := q.CreateArticle(ctx, database.CreateArticleParams{UserID: userID, Title: fmt.Sprintf("title%d", i), Text: "text"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, resp.ArticleID)
+	}
+
+	if err := q.DeleteArticle(ctx, database.DeleteArticleParams{ArticleID: ids[1], UserID: userID}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("削除済みの記事は取得しない", func(t *testing.T) {
+		resp, err := q.GetArticles(ctx, database.GetArticlesParams{UserID: userID})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		var got []int64
+		for _, a := range resp.Articles {
+			got = append(got, a.ID)
+		}
+		if expect := []int64{ids[2], ids[0]}; !reflect.DeepEqual(got, expect) {
+			t.Errorf("Expect: %v, Got: %v", expect, got)
+		}
+
+		if _, err := q.GetArticle(ctx, database.GetArticleParams{ArticleID: ids[1], UserID: userID}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expect: %v, Got: %v", sql.ErrNoRows, err)
+		}
+	})
+
+	t.Run("他のユーザーの記事は取得しない", func(t *testing.T) {
+		if _, err := q.GetArticle(ctx, database.GetArticleParams{ArticleID: ids[0], UserID: otherID}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expect: %v, Got: %v", sql.ErrNoRows, err)
+		}
+	})
+
+	t.Run("一括削除", func(t *testing.T) {
+		resp, err := q.BatchDeleteArticles(ctx, database.BatchDeleteArticlesParams{
+			ArticleIDs:   []int64{ids[0], ids[1], 100},
+			UserID:       userID,
+			AllOrNothing: true,
+		})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if len(resp.DeletedIDs) != 0 || !reflect.DeepEqual(resp.MissingIDs, []int64{ids[1], 100}) {
+			t.Errorf("unexpected result: %+v", resp)
+		}
+
+		resp, err = q.BatchDeleteArticles(ctx, database.BatchDeleteArticlesParams{
+			ArticleIDs: []int64{ids[0], ids[1]},
+			UserID:     userID,
+		})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if !reflect.DeepEqual(resp.DeletedIDs, []int64{ids[0]}) || !reflect.DeepEqual(resp.MissingIDs, []int64{ids[1]}) {
+			t.Errorf("unexpected result: %+v", resp)
+		}
+	})
+}
+
+func TestQuerier_GetArticles_sortByUpdatedAt(t *testing.T) {
+	ctx := context.Background()
+	q := NewQuerier()
+	userID := signUp(t, q, "test@example.com")
+
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		resp, err := q.CreateArticle(ctx, database.CreateArticleParams{UserID: userID, Title: "title", Text: "text"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, resp.ArticleID)
+	}
+
+	// 最初に作成した記事を更新すると更新日時が最も新しくなる
+	time.Sleep(time.Millisecond)
+	if err := q.UpdateArticle(ctx, database.UpdateArticleParams{ArticleID: ids[0], UserID: userID, Title: "updated", Text: "text"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		ascending bool
+		expect    []int64
+	}{
+		{name: "降順", expect: []int64{ids[0], ids[2], ids[1]}},
+		{name: "昇順", ascending: true, expect: []int64{ids[1], ids[2], ids[0]}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := q.GetArticles(ctx, database.GetArticlesParams{UserID: userID, SortBy: database.ArticleSortUpdatedAt, Ascending: tt.ascending})
+			if err != nil {
+				t.Fatalf("err should be nil: %v", err)
+			}
+
+			var got []int64
+			for _, a := range resp.Articles {
+				got = append(got, a.ID)
+			}
+			if !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("Expect: %v, Got: %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestQuerier_BatchCreateArticles(t *testing.T) {
+	ctx := context.Background()
+	q := NewQuerier()
+	userID := signUp(t, q, "test@example.com")
+
+	externalID := sql.NullString{String: "ext-1", Valid: true}
+
+	if _, err := q.BatchCreateArticles(ctx, database.BatchCreateArticlesParams{
+		UserID:   userID,
+		Articles: []database.BatchCreateArticle{{ExternalID: externalID, Title: "title", Text: "text"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重複する外部IDを含む場合は1件も登録しない
+	_, err := q.BatchCreateArticles(ctx, database.BatchCreateArticlesParams{
+		UserID: userID,
+		Articles: []database.BatchCreateArticle{
+			{Title: "title", Text: "text"},
+			{ExternalID: externalID, Title: "title", Text: "text"},
+		},
+	})
+	if !database.IsUniqueViolation(err) {
+		t.Errorf("err should be unique violation: %v", err)
+	}
+
+	resp, err := q.GetArticles(ctx, database.GetArticlesParams{UserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Articles) != 1 {
+		t.Errorf("Expect: %v, Got: %v", 1, len(resp.Articles))
+	}
+}
+
+func TestQuerier_WithTx(t *testing.T) {
+	ctx := context.Background()
+	q := NewQuerier()
+
+	errRollback := errors.New("rollback")
+
+	err := q.WithTx(ctx, func(tx database.Querier) error {
+		if _, err := tx.SignUp(ctx, database.SignUpParams{Email: "test@example.com", Password: "hash"}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("Expect: %v, Got: %v", errRollback, err)
+	}
+
+	if _, err := q.Login(ctx, database.LoginParams{Email: "test@example.com"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("user should be rolled back: %v", err)
+	}
+
+	err = q.WithTx(ctx, func(tx database.Querier) error {
+		_, err := tx.SignUp(ctx, database.SignUpParams{Email: "test@example.com", Password: "hash"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	if _, err := q.Login(ctx, database.LoginParams{Email: "test@example.com"}); err != nil {
+		t.Errorf("user should be committed: %v", err)
+	}
+}
+
+func TestQuerier_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	q := NewQuerier()
+	userID := signUp(t, q, "test@example.com")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.CreateArticle(ctx, database.CreateArticleParams{UserID: userID, Title: "title", Text: "text"})
+			q.GetArticles(ctx, database.GetArticlesParams{UserID: userID})
+		}()
+	}
+	wg.Wait()
+
+	resp, err := q.GetArticles(ctx, database.GetArticlesParams{UserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Articles) != 20 {
+		t.Errorf("Expect: %v, Got: %v", 20, len(resp.Articles))
+	}
+}
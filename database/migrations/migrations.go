@@ -0,0 +1,78 @@
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/migrate"
+)
+
+// SQLは実行ファイルに埋め込むため、ソースツリーがない環境でも適用できる
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var sqlMigrations embed.FS
+
+// dirs はダイアレクトごとのSQLマイグレーションを置くディレクトリ。
+// SQLの方言が異なるため、同じ名前のマイグレーションをそれぞれのディレクトリに用意する
+var dirs = map[dialect.Name]string{
+	dialect.MySQL:  "mysql",
+	dialect.PG:     "postgres",
+	dialect.SQLite: "sqlite",
+}
+
+var migrations = map[dialect.Name]*migrate.Migrations{}
+
+func init() {
+	for name, dir := range dirs {
+		fsys, err := fs.Sub(sqlMigrations, dir)
+		if err != nil {
+			panic(err)
+		}
+
+		m := migrate.NewMigrations()
+		if err := m.Discover(fsys); err != nil {
+			panic(err)
+		}
+		migrations[name] = m
+	}
+}
+
+// For はダイアレクトに対応するマイグレーションの一覧を返す
+func For(name dialect.Name) (*migrate.Migrations, error) {
+	m, ok := migrations[name]
+	if !ok {
+		return nil, fmt.Errorf("migrations for %s are not supported", name)
+	}
+
+	return m, nil
+}
+
+// Create はdirの下の全てのダイアレクトのディレクトリに同じ名前で空のup/downのSQLファイルを作成し、作成したファイルのパスを返す。
+// SQLは実行ファイルに埋め込まれるため、dirにはソースツリーのdatabase/migrationsを指定する
+func Create(dir, name string) ([]string, error) {
+	for _, sub := range dirs {
+		if info, err := os.Stat(filepath.Join(dir, sub)); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a migrations directory: %s is missing", dir, sub)
+		}
+	}
+
+	prefix := time.Now().UTC().Format("20060102150405") + "_" + name
+
+	var paths []string
+	for _, sub := range dirs {
+		for _, suffix := range []string{".up.sql", ".down.sql"} {
+			path := filepath.Join(dir, sub, prefix+suffix)
+			if err := os.WriteFile(path, []byte("SELECT 1;\n"), 0o644); err != nil {
+				return paths, err
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, nil
+}
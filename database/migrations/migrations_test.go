@@ -0,0 +1,85 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uptrace/bun/dialect"
+)
+
+func TestMigrations(t *testing.T) {
+	mysql, err := For(dialect.MySQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := mysql.Sorted()
+	if len(expect) == 0 {
+		t.Fatal("migrations should not be empty")
+	}
+
+	for name := range dirs {
+		t.Run(name.String(), func(t *testing.T) {
+			m, err := For(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ms := m.Sorted()
+			for _, m := range ms {
+				if m.Up == nil {
+					t.Errorf("%s: up migration is missing", m.Name)
+				}
+				if m.Down == nil {
+					t.Errorf("%s: down migration is missing", m.Name)
+				}
+			}
+
+			// どのダイアレクトでも同じマイグレーションを適用できるよう名前が揃っているか確認する
+			if len(ms) != len(expect) {
+				t.Fatalf("Expect: %d migrations, Got: %d", len(expect), len(ms))
+			}
+			for i := range ms {
+				if ms[i].Name != expect[i].Name || ms[i].Comment != expect[i].Comment {
+					t.Errorf("Expect: %s_%s, Got: %s_%s", expect[i].Name, expect[i].Comment, ms[i].Name, ms[i].Comment)
+				}
+			}
+		})
+	}
+
+	if _, err := For(dialect.MSSQL); err == nil {
+		t.Error("err should not be nil for unsupported dialect")
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Create(dir, "add_column"); err == nil {
+		t.Error("err should not be nil for a directory without driver directories")
+	}
+
+	for _, sub := range dirs {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := Create(dir, "add_column")
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if len(paths) != len(dirs)*2 {
+		t.Fatalf("Expect: %d files, Got: %d", len(dirs)*2, len(paths))
+	}
+	for _, path := range paths {
+		if !strings.HasPrefix(path, dir) || !strings.Contains(filepath.Base(path), "_add_column.") {
+			t.Errorf("unexpected path: %s", path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("err should be nil: %v", err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+CREATE TABLE IF NOT EXISTS "users" (
+  "id" BIGSERIAL NOT NULL,
+  "email" VARCHAR(255) NOT NULL,
+  "password" VARCHAR(255) NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT current_timestamp,
+  "updated_at" timestamptz NOT NULL DEFAULT current_timestamp,
+  "deleted_at" timestamptz,
+  PRIMARY KEY ("id"),
+  UNIQUE ("email")
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "sessions" (
+  "access_token" VARCHAR(255) NOT NULL,
+  "user_id" BIGINT NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT current_timestamp,
+  "expired_at" timestamptz NOT NULL,
+  PRIMARY KEY ("access_token"),
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "articles" (
+  "id" BIGSERIAL NOT NULL,
+  "user_id" BIGINT NOT NULL,
+  "external_id" VARCHAR(255),
+  "title" VARCHAR(255) NOT NULL,
+  "description" VARCHAR(255),
+  "text" text NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT current_timestamp,
+  "updated_at" timestamptz NOT NULL DEFAULT current_timestamp,
+  "deleted_at" timestamptz,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "user_id_external_id" UNIQUE ("user_id", "external_id"),
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "attachments" (
+  "id" BIGSERIAL NOT NULL,
+  "article_id" BIGINT NOT NULL,
+  "user_id" BIGINT NOT NULL,
+  "filename" VARCHAR(255) NOT NULL,
+  "content_type" VARCHAR(255) NOT NULL,
+  "size" BIGINT NOT NULL,
+  "sha256" char(64) NOT NULL,
+  "storage_key" VARCHAR(255) NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT current_timestamp,
+  PRIMARY KEY ("id"),
+  UNIQUE ("storage_key"),
+  FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "thumbnails" (
+  "id" BIGSERIAL NOT NULL,
+  "attachment_id" BIGINT NOT NULL,
+  "size" BIGINT NOT NULL,
+  "width" BIGINT NOT NULL,
+  "height" BIGINT NOT NULL,
+  "content_type" VARCHAR(255) NOT NULL,
+  "storage_key" VARCHAR(255) NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT current_timestamp,
+  PRIMARY KEY ("id"),
+  UNIQUE ("storage_key"),
+  CONSTRAINT "attachment_id_size" UNIQUE ("attachment_id", "size"),
+  FOREIGN KEY (attachment_id) REFERENCES attachments (id) ON DELETE CASCADE
+);
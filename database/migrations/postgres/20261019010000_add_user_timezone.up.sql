@@ -0,0 +1 @@
+ALTER TABLE "users" ADD COLUMN "timezone" VARCHAR(64) NOT NULL DEFAULT 'UTC';
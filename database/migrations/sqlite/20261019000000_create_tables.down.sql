@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS "thumbnails";
+
+--bun:split
+
+DROP TABLE IF EXISTS "attachments";
+
+--bun:split
+
+DROP TABLE IF EXISTS "articles";
+
+--bun:split
+
+DROP TABLE IF EXISTS "sessions";
+
+--bun:split
+
+DROP TABLE IF EXISTS "users";
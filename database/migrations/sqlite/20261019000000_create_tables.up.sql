@@ -0,0 +1,69 @@
+CREATE TABLE IF NOT EXISTS "users" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "email" VARCHAR(255) NOT NULL,
+  "password" VARCHAR(255) NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  "updated_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  "deleted_at" TIMESTAMP,
+  UNIQUE ("email")
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "sessions" (
+  "access_token" VARCHAR(255) NOT NULL,
+  "user_id" INTEGER NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  "expired_at" TIMESTAMP NOT NULL,
+  PRIMARY KEY ("access_token"),
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "articles" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "user_id" INTEGER NOT NULL,
+  "external_id" VARCHAR(255),
+  "title" VARCHAR(255) NOT NULL,
+  "description" VARCHAR(255),
+  "text" TEXT NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  "updated_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  "deleted_at" TIMESTAMP,
+  CONSTRAINT "user_id_external_id" UNIQUE ("user_id", "external_id"),
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "attachments" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "article_id" INTEGER NOT NULL,
+  "user_id" INTEGER NOT NULL,
+  "filename" VARCHAR(255) NOT NULL,
+  "content_type" VARCHAR(255) NOT NULL,
+  "size" INTEGER NOT NULL,
+  "sha256" CHAR(64) NOT NULL,
+  "storage_key" VARCHAR(255) NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  UNIQUE ("storage_key"),
+  FOREIGN KEY (article_id) REFERENCES articles (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS "thumbnails" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "attachment_id" INTEGER NOT NULL,
+  "size" INTEGER NOT NULL,
+  "width" INTEGER NOT NULL,
+  "height" INTEGER NOT NULL,
+  "content_type" VARCHAR(255) NOT NULL,
+  "storage_key" VARCHAR(255) NOT NULL,
+  "created_at" TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  UNIQUE ("storage_key"),
+  CONSTRAINT "attachment_id_size" UNIQUE ("attachment_id", "size"),
+  FOREIGN KEY (attachment_id) REFERENCES attachments (id) ON DELETE CASCADE
+);
@@ -35,6 +35,51 @@ func (m *MockQuerier) EXPECT() *MockQuerierMockRecorder {
 	return m.recorder
 }
 
+// BatchCreateArticles mocks base method.
+func (m *MockQuerier) BatchCreateArticles(arg0 context.Context, arg1 database.BatchCreateArticlesParams) (*database.BatchCreateArticlesResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchCreateArticles", arg0, arg1)
+	ret0, _ := ret[0].(*database.BatchCreateArticlesResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchCreateArticles indicates an expected call of BatchCreateArticles.
+func (mr *MockQuerierMockRecorder) BatchCreateArticles(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchCreateArticles", reflect.TypeOf((*MockQuerier)(nil).BatchCreateArticles), arg0, arg1)
+}
+
+// BatchDeleteArticles mocks base method.
+func (m *MockQuerier) BatchDeleteArticles(arg0 context.Context, arg1 database.BatchDeleteArticlesParams) (*database.BatchDeleteArticlesResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchDeleteArticles", arg0, arg1)
+	ret0, _ := ret[0].(*database.BatchDeleteArticlesResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchDeleteArticles indicates an expected call of BatchDeleteArticles.
+func (mr *MockQuerierMockRecorder) BatchDeleteArticles(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchDeleteArticles", reflect.TypeOf((*MockQuerier)(nil).BatchDeleteArticles), arg0, arg1)
+}
+
+// BatchGetArticles mocks base method.
+func (m *MockQuerier) BatchGetArticles(arg0 context.Context, arg1 database.BatchGetArticlesParams) (*database.BatchGetArticlesResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchGetArticles", arg0, arg1)
+	ret0, _ := ret[0].(*database.BatchGetArticlesResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchGetArticles indicates an expected call of BatchGetArticles.
+func (mr *MockQuerierMockRecorder) BatchGetArticles(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchGetArticles", reflect.TypeOf((*MockQuerier)(nil).BatchGetArticles), arg0, arg1)
+}
+
 // CreateArticle mocks base method.
 func (m *MockQuerier) CreateArticle(arg0 context.Context, arg1 database.CreateArticleParams) (*database.CreateArticleResult, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +95,21 @@ func (mr *MockQuerierMockRecorder) CreateArticle(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateArticle", reflect.TypeOf((*MockQuerier)(nil).CreateArticle), arg0, arg1)
 }
 
+// CreateAttachment mocks base method.
+func (m *MockQuerier) CreateAttachment(arg0 context.Context, arg1 database.CreateAttachmentParams) (*database.CreateAttachmentResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAttachment", arg0, arg1)
+	ret0, _ := ret[0].(*database.CreateAttachmentResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAttachment indicates an expected call of CreateAttachment.
+func (mr *MockQuerierMockRecorder) CreateAttachment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAttachment", reflect.TypeOf((*MockQuerier)(nil).CreateAttachment), arg0, arg1)
+}
+
 // CreateSession mocks base method.
 func (m *MockQuerier) CreateSession(arg0 context.Context, arg1 database.CreateSessionParams) error {
 	m.ctrl.T.Helper()
@@ -64,6 +124,20 @@ func (mr *MockQuerierMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockQuerier)(nil).CreateSession), arg0, arg1)
 }
 
+// CreateThumbnail mocks base method.
+func (m *MockQuerier) CreateThumbnail(arg0 context.Context, arg1 database.CreateThumbnailParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateThumbnail", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateThumbnail indicates an expected call of CreateThumbnail.
+func (mr *MockQuerierMockRecorder) CreateThumbnail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateThumbnail", reflect.TypeOf((*MockQuerier)(nil).CreateThumbnail), arg0, arg1)
+}
+
 // DeleteArticle mocks base method.
 func (m *MockQuerier) DeleteArticle(arg0 context.Context, arg1 database.DeleteArticleParams) error {
 	m.ctrl.T.Helper()
@@ -78,6 +152,20 @@ func (mr *MockQuerierMockRecorder) DeleteArticle(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteArticle", reflect.TypeOf((*MockQuerier)(nil).DeleteArticle), arg0, arg1)
 }
 
+// DeleteSession mocks base method.
+func (m *MockQuerier) DeleteSession(arg0 context.Context, arg1 database.DeleteSessionParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockQuerierMockRecorder) DeleteSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockQuerier)(nil).DeleteSession), arg0, arg1)
+}
+
 // GetArticle mocks base method.
 func (m *MockQuerier) GetArticle(arg0 context.Context, arg1 database.GetArticleParams) (*database.GetArticleResult, error) {
 	m.ctrl.T.Helper()
@@ -93,6 +181,36 @@ func (mr *MockQuerierMockRecorder) GetArticle(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArticle", reflect.TypeOf((*MockQuerier)(nil).GetArticle), arg0, arg1)
 }
 
+// GetArticleAttachments mocks base method.
+func (m *MockQuerier) GetArticleAttachments(arg0 context.Context, arg1 database.GetArticleAttachmentsParams) (*database.GetArticleAttachmentsResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArticleAttachments", arg0, arg1)
+	ret0, _ := ret[0].(*database.GetArticleAttachmentsResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArticleAttachments indicates an expected call of GetArticleAttachments.
+func (mr *MockQuerierMockRecorder) GetArticleAttachments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArticleAttachments", reflect.TypeOf((*MockQuerier)(nil).GetArticleAttachments), arg0, arg1)
+}
+
+// GetArticleExternalIDs mocks base method.
+func (m *MockQuerier) GetArticleExternalIDs(arg0 context.Context, arg1 database.GetArticleExternalIDsParams) (*database.GetArticleExternalIDsResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArticleExternalIDs", arg0, arg1)
+	ret0, _ := ret[0].(*database.GetArticleExternalIDsResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArticleExternalIDs indicates an expected call of GetArticleExternalIDs.
+func (mr *MockQuerierMockRecorder) GetArticleExternalIDs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArticleExternalIDs", reflect.TypeOf((*MockQuerier)(nil).GetArticleExternalIDs), arg0, arg1)
+}
+
 // GetArticles mocks base method.
 func (m *MockQuerier) GetArticles(arg0 context.Context, arg1 database.GetArticlesParams) (*database.GetArticlesResult, error) {
 	m.ctrl.T.Helper()
@@ -108,6 +226,21 @@ func (mr *MockQuerierMockRecorder) GetArticles(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArticles", reflect.TypeOf((*MockQuerier)(nil).GetArticles), arg0, arg1)
 }
 
+// GetAttachment mocks base method.
+func (m *MockQuerier) GetAttachment(arg0 context.Context, arg1 database.GetAttachmentParams) (*database.GetAttachmentResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttachment", arg0, arg1)
+	ret0, _ := ret[0].(*database.GetAttachmentResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttachment indicates an expected call of GetAttachment.
+func (mr *MockQuerierMockRecorder) GetAttachment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachment", reflect.TypeOf((*MockQuerier)(nil).GetAttachment), arg0, arg1)
+}
+
 // GetSession mocks base method.
 func (m *MockQuerier) GetSession(arg0 context.Context, arg1 database.GetSessionParams) (*database.GetSessionResult, error) {
 	m.ctrl.T.Helper()
@@ -123,6 +256,21 @@ func (mr *MockQuerierMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockQuerier)(nil).GetSession), arg0, arg1)
 }
 
+// GetUserTimezone mocks base method.
+func (m *MockQuerier) GetUserTimezone(arg0 context.Context, arg1 database.GetUserTimezoneParams) (*database.GetUserTimezoneResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserTimezone", arg0, arg1)
+	ret0, _ := ret[0].(*database.GetUserTimezoneResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserTimezone indicates an expected call of GetUserTimezone.
+func (mr *MockQuerierMockRecorder) GetUserTimezone(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTimezone", reflect.TypeOf((*MockQuerier)(nil).GetUserTimezone), arg0, arg1)
+}
+
 // Login mocks base method.
 func (m *MockQuerier) Login(arg0 context.Context, arg1 database.LoginParams) (*database.LoginResult, error) {
 	m.ctrl.T.Helper()
@@ -166,3 +314,45 @@ func (mr *MockQuerierMockRecorder) UpdateArticle(arg0, arg1 interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateArticle", reflect.TypeOf((*MockQuerier)(nil).UpdateArticle), arg0, arg1)
 }
+
+// UpdateArticleText mocks base method.
+func (m *MockQuerier) UpdateArticleText(arg0 context.Context, arg1 database.UpdateArticleTextParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateArticleText", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateArticleText indicates an expected call of UpdateArticleText.
+func (mr *MockQuerierMockRecorder) UpdateArticleText(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateArticleText", reflect.TypeOf((*MockQuerier)(nil).UpdateArticleText), arg0, arg1)
+}
+
+// UpdateUserTimezone mocks base method.
+func (m *MockQuerier) UpdateUserTimezone(arg0 context.Context, arg1 database.UpdateUserTimezoneParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserTimezone", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserTimezone indicates an expected call of UpdateUserTimezone.
+func (mr *MockQuerierMockRecorder) UpdateUserTimezone(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserTimezone", reflect.TypeOf((*MockQuerier)(nil).UpdateUserTimezone), arg0, arg1)
+}
+
+// WithTx mocks base method.
+func (m *MockQuerier) WithTx(ctx context.Context, fn func(database.Querier) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockQuerierMockRecorder) WithTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockQuerier)(nil).WithTx), ctx, fn)
+}
@@ -15,6 +15,14 @@ func (s *User) BeforeDropTable(ctx context.Context, query *bun.DropTableQuery) e
 		return err
 	}
 
+	if _, err := query.NewDropTable().IfExists().Table("thumbnails").Exec(ctx); err != nil {
+		return err
+	}
+
+	if _, err := query.NewDropTable().IfExists().Table("attachments").Exec(ctx); err != nil {
+		return err
+	}
+
 	if _, err := query.NewDropTable().IfExists().Table("articles").Exec(ctx); err != nil {
 		return err
 	}
@@ -28,6 +36,7 @@ type User struct {
 	ID        int64        `bun:"id,pk,autoincrement"`
 	Email     string       `bun:"email,notnull,unique"`
 	Password  string       `bun:"password,notnull"`
+	Timezone  string       `bun:"timezone,notnull,default:'UTC'"`
 	CreatedAt time.Time    `bun:"created_at,notnull,type:timestamp,default:current_timestamp"`
 	UpdatedAt time.Time    `bun:"updated_at,notnull,type:timestamp,default:current_timestamp"`
 	DeletedAt sql.NullTime `bun:"deleted_at,type:timestamp,soft_delete"`
@@ -60,7 +69,8 @@ type Article struct {
 	bun.BaseModel `bun:"table:articles,alias:a"`
 
 	ID          int64          `bun:"id,pk,autoincrement"`
-	UserID      int64          `bun:"user_id,notnull"`
+	UserID      int64          `bun:"user_id,notnull,unique:user_id_external_id"`
+	ExternalID  sql.NullString `bun:"external_id,unique:user_id_external_id"`
 	Title       string         `bun:"title,notnull"`
 	Description sql.NullString `bun:"description"`
 	Text        string         `bun:"text,notnull,type:text"`
@@ -68,3 +78,47 @@ type Article struct {
 	UpdatedAt   time.Time      `bun:"updated_at,notnull,type:timestamp,default:current_timestamp"`
 	DeletedAt   sql.NullTime   `bun:"deleted_at,type:timestamp,soft_delete"`
 }
+
+var _ bun.BeforeCreateTableHook = (*Attachment)(nil)
+
+func (a *Attachment) BeforeCreateTable(_ context.Context, query *bun.CreateTableQuery) error {
+	query.ForeignKey("(article_id) REFERENCES articles (id) ON DELETE CASCADE")
+	query.ForeignKey("(user_id) REFERENCES users (id) ON DELETE CASCADE")
+	return nil
+}
+
+type Attachment struct {
+	bun.BaseModel `bun:"table:attachments,alias:at"`
+
+	ID          int64     `bun:"id,pk,autoincrement"`
+	ArticleID   int64     `bun:"article_id,notnull"`
+	UserID      int64     `bun:"user_id,notnull"`
+	Filename    string    `bun:"filename,notnull"`
+	ContentType string    `bun:"content_type,notnull"`
+	Size        int64     `bun:"size,notnull"`
+	SHA256      string    `bun:"sha256,notnull,type:char(64)"`
+	StorageKey  string    `bun:"storage_key,notnull,unique"`
+	CreatedAt   time.Time `bun:"created_at,notnull,type:timestamp,default:current_timestamp"`
+
+	Thumbnails []*Thumbnail `bun:"rel:has-many,join:id=attachment_id"`
+}
+
+var _ bun.BeforeCreateTableHook = (*Thumbnail)(nil)
+
+func (t *Thumbnail) BeforeCreateTable(_ context.Context, query *bun.CreateTableQuery) error {
+	query.ForeignKey("(attachment_id) REFERENCES attachments (id) ON DELETE CASCADE")
+	return nil
+}
+
+type Thumbnail struct {
+	bun.BaseModel `bun:"table:thumbnails,alias:th"`
+
+	ID           int64     `bun:"id,pk,autoincrement"`
+	AttachmentID int64     `bun:"attachment_id,notnull,unique:attachment_id_size"`
+	Size         int       `bun:"size,notnull,unique:attachment_id_size"`
+	Width        int       `bun:"width,notnull"`
+	Height       int       `bun:"height,notnull"`
+	ContentType  string    `bun:"content_type,notnull"`
+	StorageKey   string    `bun:"storage_key,notnull,unique"`
+	CreatedAt    time.Time `bun:"created_at,notnull,type:timestamp,default:current_timestamp"`
+}
@@ -4,14 +4,30 @@ import "context"
 
 //go:generate mockgen -source=$GOFILE -destination=mock/mock_$GOFILE
 type Querier interface {
+	WithTx(ctx context.Context, fn func(Querier) error) error
+
 	SignUp(context.Context, SignUpParams) (*SignUpResult, error)
 	Login(context.Context, LoginParams) (*LoginResult, error)
 	CreateSession(context.Context, CreateSessionParams) error
 	GetSession(context.Context, GetSessionParams) (*GetSessionResult, error)
+	DeleteSession(context.Context, DeleteSessionParams) error
+	GetUserTimezone(context.Context, GetUserTimezoneParams) (*GetUserTimezoneResult, error)
+	UpdateUserTimezone(context.Context, UpdateUserTimezoneParams) error
 
 	CreateArticle(context.Context, CreateArticleParams) (*CreateArticleResult, error)
 	GetArticles(context.Context, GetArticlesParams) (*GetArticlesResult, error)
 	GetArticle(context.Context, GetArticleParams) (*GetArticleResult, error)
 	UpdateArticle(context.Context, UpdateArticleParams) error
+	UpdateArticleText(context.Context, UpdateArticleTextParams) error
 	DeleteArticle(context.Context, DeleteArticleParams) error
+
+	BatchCreateArticles(context.Context, BatchCreateArticlesParams) (*BatchCreateArticlesResult, error)
+	BatchGetArticles(context.Context, BatchGetArticlesParams) (*BatchGetArticlesResult, error)
+	BatchDeleteArticles(context.Context, BatchDeleteArticlesParams) (*BatchDeleteArticlesResult, error)
+	GetArticleExternalIDs(context.Context, GetArticleExternalIDsParams) (*GetArticleExternalIDsResult, error)
+
+	CreateAttachment(context.Context, CreateAttachmentParams) (*CreateAttachmentResult, error)
+	GetAttachment(context.Context, GetAttachmentParams) (*GetAttachmentResult, error)
+	GetArticleAttachments(context.Context, GetArticleAttachmentsParams) (*GetArticleAttachmentsResult, error)
+	CreateThumbnail(context.Context, CreateThumbnailParams) error
 }
@@ -0,0 +1,461 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/database/cache"
+	"sample-grpc-server/database/memory"
+	"sample-grpc-server/database/model"
+)
+
+// testQuerier はdatabase.Querierの実装が同じように振る舞うことを確認する。
+// newQuerierはサブテストごとに空のデータで呼ばれる
+func testQuerier(t *testing.T, newQuerier func(t *testing.T) database.Querier) {
+	ctx := context.Background()
+
+	signUp := func(t *testing.T, q database.Querier, email string) int64 {
+		t.Helper()
+
+		resp, err := q.SignUp(ctx, database.SignUpParams{Email: email, Password: "hash"})
+		if err != nil {
+			t.Fatalf("failed to sign up: %v", err)
+		}
+		return resp.UserID
+	}
+
+	createArticle := func(t *testing.T, q database.Querier, userID int64, title string) int64 {
+		t.Helper()
+
+		resp, err := q.CreateArticle(ctx, database.CreateArticleParams{
+			UserID:      userID,
+			Title:       title,
+			Description: sql.NullString{String: "description", Valid: true},
+			Text:        "text",
+		})
+		if err != nil {
+			t.Fatalf("failed to create article: %v", err)
+		}
+		return resp.ArticleID
+	}
+
+	t.Run("ユーザー", func(t *testing.T) {
+		q := newQuerier(t)
+		userID := signUp(t, q, "test@example.com")
+
+		if _, err := q.SignUp(ctx, database.SignUpParams{Email: "test@example.com", Password: "hash"}); !database.IsUniqueViolation(err) {
+			t.Errorf("duplicate email should be unique violation: %v", err)
+		}
+
+		login, err := q.Login(ctx, database.LoginParams{Email: "test@example.com"})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if login.UserID != userID || login.Password != "hash" {
+			t.Errorf("unexpected login result: %+v", login)
+		}
+
+		if _, err := q.Login(ctx, database.LoginParams{Email: "unknown@example.com"}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expect: %v, Got: %v", sql.ErrNoRows, err)
+		}
+
+		tz, err := q.GetUserTimezone(ctx, database.GetUserTimezoneParams{UserID: userID})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if tz.Timezone != "UTC" {
+			t.Errorf("Expect: %v, Got: %v", "UTC", tz.Timezone)
+		}
+
+		if err := q.UpdateUserTimezone(ctx, database.UpdateUserTimezoneParams{UserID: userID, Timezone: "Asia/Tokyo"}); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		tz, err = q.GetUserTimezone(ctx, database.GetUserTimezoneParams{UserID: userID})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if tz.Timezone != "Asia/Tokyo" {
+			t.Errorf("Expect: %v, Got: %v", "Asia/Tokyo", tz.Timezone)
+		}
+
+		if _, err := q.GetUserTimezone(ctx, database.GetUserTimezoneParams{UserID: userID + 100}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expect: %v, Got: %v", sql.ErrNoRows, err)
+		}
+	})
+
+	t.Run("セッション", func(t *testing.T) {
+		q := newQuerier(t)
+		userID := signUp(t, q, "test@example.com")
+
+		for token, expiredAt := range map[string]time.Time{
+			"valid":   time.Now().Add(time.Hour),
+			"expired": time.Now().Add(-time.Hour),
+		} {
+			if err := q.CreateSession(ctx, database.CreateSessionParams{AccessToken: token, UserID: userID, ExpiredAt: expiredAt}); err != nil {
+				t.Fatalf("failed to create session: %v", err)
+			}
+		}
+
+		session, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "valid"})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if session.ID != userID {
+			t.Errorf("Expect: %v, Got: %v", userID, session.ID)
+		}
+		if !session.ExpiredAt.After(time.Now()) {
+			t.Errorf("expired_at should be returned: %v", session.ExpiredAt)
+		}
+
+		if err := q.DeleteSession(ctx, database.DeleteSessionParams{AccessToken: "valid"}); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if _, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "valid"}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("deleted session should not be found: %v", err)
+		}
+
+		for _, token := range []string{"expired", "unknown"} {
+			if _, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: token}); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("%s: Expect: %v, Got: %v", token, sql.ErrNoRows, err)
+			}
+		}
+
+		err = q.CreateSession(ctx, database.CreateSessionParams{AccessToken: "orphan", UserID: userID + 100, ExpiredAt: time.Now().Add(time.Hour)})
+		if !database.IsForeignKeyViolation(err) {
+			t.Errorf("session of unknown user should be foreign key violation: %v", err)
+		}
+	})
+
+	t.Run("記事", func(t *testing.T) {
+		q := newQuerier(t)
+		userID := signUp(t, q, "test@example.com")
+		otherID := signUp(t, q, "other@example.com")
+
+		// データベースによっては秒未満を切り捨てるため1秒の幅を持たせる
+		before := time.Now().Add(-time.Second)
+		first := createArticle(t, q, userID, "first")
+		after := time.Now().Add(time.Second)
+		second := createArticle(t, q, userID, "second")
+		createArticle(t, q, otherID, "other")
+
+		got, err := q.GetArticle(ctx, database.GetArticleParams{ArticleID: first, UserID: userID})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		a := got.Article
+		if a.ID != first || a.Title != "first" || a.Description.String != "description" || a.Text != "text" {
+			t.Errorf("unexpected article: %+v", a)
+		}
+		if a.CreatedAt.Before(before) || a.CreatedAt.After(after) || !a.UpdatedAt.Equal(a.CreatedAt) || a.DeletedAt.Valid {
+			t.Errorf("unexpected timestamps: created_at=%v, updated_at=%v, deleted_at=%v", a.CreatedAt, a.UpdatedAt, a.DeletedAt)
+		}
+
+		if _, err := q.GetArticle(ctx, database.GetArticleParams{ArticleID: first, UserID: otherID}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("article of other user should not be found: %v", err)
+		}
+
+		err = q.UpdateArticle(ctx, database.UpdateArticleParams{ArticleID: first, UserID: userID, Title: "updated", Text: "updated text"})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		got, err = q.GetArticle(ctx, database.GetArticleParams{ArticleID: first, UserID: userID})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if got.Article.Title != "updated" || got.Article.Description.Valid || got.Article.Text != "updated text" {
+			t.Errorf("article should be updated: %+v", got.Article)
+		}
+		if !got.Article.CreatedAt.Equal(a.CreatedAt) || got.Article.UpdatedAt.Before(a.UpdatedAt) {
+			t.Errorf("only updated_at should be changed: created_at=%v, updated_at=%v", got.Article.CreatedAt, got.Article.UpdatedAt)
+		}
+
+		// 本文だけの更新はタイトルを変えない
+		if err := q.UpdateArticleText(ctx, database.UpdateArticleTextParams{ArticleID: first, UserID: userID, Text: "edited"}); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		got, err = q.GetArticle(ctx, database.GetArticleParams{ArticleID: first, UserID: userID})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if got.Article.Title != "updated" || got.Article.Text != "edited" {
+			t.Errorf("only text should be updated: %+v", got.Article)
+		}
+
+		if err := q.DeleteArticle(ctx, database.DeleteArticleParams{ArticleID: second, UserID: userID}); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if _, err := q.GetArticle(ctx, database.GetArticleParams{ArticleID: second, UserID: userID}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("deleted article should not be found: %v", err)
+		}
+
+		articles, err := q.GetArticles(ctx, database.GetArticlesParams{UserID: userID})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if ids := articleIDs(articles.Articles); !reflect.DeepEqual(ids, []int64{first}) {
+			t.Errorf("Expect: %v, Got: %v", []int64{first}, ids)
+		}
+
+		_, err = q.CreateArticle(ctx, database.CreateArticleParams{UserID: userID + 100, Title: "title", Text: "text"})
+		if !database.IsForeignKeyViolation(err) {
+			t.Errorf("article of unknown user should be foreign key violation: %v", err)
+		}
+	})
+
+	t.Run("記事の並び順", func(t *testing.T) {
+		q := newQuerier(t)
+		userID := signUp(t, q, "test@example.com")
+
+		b := createArticle(t, q, userID, "b")
+		c := createArticle(t, q, userID, "c")
+		a := createArticle(t, q, userID, "a")
+
+		tests := []struct {
+			name   string
+			params database.GetArticlesParams
+			expect []int64
+		}{
+			{
+				name:   "指定なしは作成日時の降順",
+				params: database.GetArticlesParams{UserID: userID},
+				expect: []int64{a, c, b},
+			},
+			{
+				name:   "作成日時の昇順",
+				params: database.GetArticlesParams{UserID: userID, Ascending: true},
+				expect: []int64{b, c, a},
+			},
+			{
+				name:   "タイトルの昇順",
+				params: database.GetArticlesParams{UserID: userID, SortBy: database.ArticleSortTitle, Ascending: true},
+				expect: []int64{a, b, c},
+			},
+			{
+				name:   "タイトルの降順",
+				params: database.GetArticlesParams{UserID: userID, SortBy: database.ArticleSortTitle},
+				expect: []int64{c, b, a},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := q.GetArticles(ctx, tt.params)
+				if err != nil {
+					t.Fatalf("err should be nil: %v", err)
+				}
+				var ids []int64
+				for _, article := range got.Articles {
+					ids = append(ids, article.ID)
+					if article.CreatedAt.IsZero() || article.UpdatedAt.IsZero() {
+						t.Errorf("timestamps should be returned: %+v", article)
+					}
+				}
+				if !reflect.DeepEqual(ids, tt.expect) {
+					t.Errorf("Expect: %v, Got: %v", tt.expect, ids)
+				}
+			})
+		}
+	})
+
+	t.Run("記事の一括操作", func(t *testing.T) {
+		q := newQuerier(t)
+		userID := signUp(t, q, "test@example.com")
+
+		created, err := q.BatchCreateArticles(ctx, database.BatchCreateArticlesParams{
+			UserID: userID,
+			Articles: []database.BatchCreateArticle{
+				{ExternalID: sql.NullString{String: "ext-1", Valid: true}, Title: "first", Text: "text"},
+				{ExternalID: sql.NullString{String: "ext-2", Valid: true}, Title: "second", Text: "text"},
+				{Title: "third", Text: "text"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if len(created.ArticleIDs) != 3 {
+			t.Fatalf("Expect: %v, Got: %v", 3, created.ArticleIDs)
+		}
+		ids := created.ArticleIDs
+
+		_, err = q.BatchCreateArticles(ctx, database.BatchCreateArticlesParams{
+			UserID: userID,
+			Articles: []database.BatchCreateArticle{
+				{Title: "fourth", Text: "text"},
+				{ExternalID: sql.NullString{String: "ext-1", Valid: true}, Title: "duplicate", Text: "text"},
+			},
+		})
+		if !database.IsUniqueViolation(err) {
+			t.Errorf("duplicate external id should be unique violation: %v", err)
+		}
+
+		got, err := q.BatchGetArticles(ctx, database.BatchGetArticlesParams{ArticleIDs: []int64{ids[0], ids[1], ids[2], ids[0] + 100}, UserID: userID})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if got := articleIDs(got.Articles); !reflect.DeepEqual(got, ids) {
+			t.Errorf("articles of failed batch should not be created: Expect: %v, Got: %v", ids, got)
+		}
+		for _, article := range got.Articles {
+			if article.CreatedAt.IsZero() || article.UpdatedAt.IsZero() {
+				t.Errorf("timestamps should be returned: %+v", article)
+			}
+		}
+
+		deleted, err := q.BatchDeleteArticles(ctx, database.BatchDeleteArticlesParams{
+			ArticleIDs:   []int64{ids[0], ids[0] + 100},
+			UserID:       userID,
+			AllOrNothing: true,
+		})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if len(deleted.DeletedIDs) != 0 || !reflect.DeepEqual(deleted.MissingIDs, []int64{ids[0] + 100}) {
+			t.Errorf("nothing should be deleted: %+v", deleted)
+		}
+
+		deleted, err = q.BatchDeleteArticles(ctx, database.BatchDeleteArticlesParams{ArticleIDs: []int64{ids[0], ids[1]}, UserID: userID})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if got := sortIDs(deleted.DeletedIDs); !reflect.DeepEqual(got, ids[:2]) || len(deleted.MissingIDs) != 0 {
+			t.Errorf("unexpected result: %+v", deleted)
+		}
+
+		// 削除済みの記事の外部IDも重複の確認の対象になる
+		external, err := q.GetArticleExternalIDs(ctx, database.GetArticleExternalIDsParams{UserID: userID, ExternalIDs: []string{"ext-1", "ext-2", "ext-3"}})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		sort.Strings(external.ExternalIDs)
+		if expect := []string{"ext-1", "ext-2"}; !reflect.DeepEqual(external.ExternalIDs, expect) {
+			t.Errorf("Expect: %v, Got: %v", expect, external.ExternalIDs)
+		}
+	})
+
+	t.Run("添付ファイル", func(t *testing.T) {
+		q := newQuerier(t)
+		userID := signUp(t, q, "test@example.com")
+		articleID := createArticle(t, q, userID, "title")
+
+		attachment, err := q.CreateAttachment(ctx, database.CreateAttachmentParams{
+			ArticleID:   articleID,
+			UserID:      userID,
+			Filename:    "image.png",
+			ContentType: "image/png",
+			Size:        100,
+			SHA256:      "0000000000000000000000000000000000000000000000000000000000000000",
+			StorageKey:  "attachments/1",
+		})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		for _, size := range []int{512, 128} {
+			err := q.CreateThumbnail(ctx, database.CreateThumbnailParams{
+				AttachmentID: attachment.AttachmentID,
+				Size:         size,
+				Width:        size,
+				Height:       size,
+				ContentType:  "image/png",
+				StorageKey:   fmt.Sprintf("thumbnails/%d", size),
+			})
+			if err != nil {
+				t.Fatalf("err should be nil: %v", err)
+			}
+		}
+
+		err = q.CreateThumbnail(ctx, database.CreateThumbnailParams{AttachmentID: attachment.AttachmentID, Size: 128, ContentType: "image/png", StorageKey: "thumbnails/other"})
+		if !database.IsUniqueViolation(err) {
+			t.Errorf("thumbnail of the same size should be unique violation: %v", err)
+		}
+
+		got, err := q.GetAttachment(ctx, database.GetAttachmentParams{AttachmentID: attachment.AttachmentID, UserID: userID})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if got.Attachment.Filename != "image.png" || got.Attachment.StorageKey != "attachments/1" || len(got.Attachment.Thumbnails) != 2 {
+			t.Errorf("unexpected attachment: %+v", got.Attachment)
+		}
+
+		list, err := q.GetArticleAttachments(ctx, database.GetArticleAttachmentsParams{ArticleIDs: []int64{articleID}, UserID: userID})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if len(list.Attachments) != 1 {
+			t.Fatalf("Expect: %v, Got: %v", 1, len(list.Attachments))
+		}
+		if th := list.Attachments[0].Thumbnails; len(th) != 2 || th[0].Size != 128 || th[1].Size != 512 {
+			t.Errorf("thumbnails should be ordered by size: %+v", th)
+		}
+
+		// 記事を削除すると添付ファイルも取得できない
+		if err := q.DeleteArticle(ctx, database.DeleteArticleParams{ArticleID: articleID, UserID: userID}); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if _, err := q.GetAttachment(ctx, database.GetAttachmentParams{AttachmentID: attachment.AttachmentID, UserID: userID}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Expect: %v, Got: %v", sql.ErrNoRows, err)
+		}
+	})
+
+	t.Run("トランザクション", func(t *testing.T) {
+		q := newQuerier(t)
+		errRollback := errors.New("rollback")
+
+		err := q.WithTx(ctx, func(tx database.Querier) error {
+			userID := signUp(t, tx, "test@example.com")
+			createArticle(t, tx, userID, "title")
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("Expect: %v, Got: %v", errRollback, err)
+		}
+		if _, err := q.Login(ctx, database.LoginParams{Email: "test@example.com"}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("user should be rolled back: %v", err)
+		}
+
+		var userID int64
+		err = q.WithTx(ctx, func(tx database.Querier) error {
+			userID = signUp(t, tx, "test@example.com")
+			return tx.CreateSession(ctx, database.CreateSessionParams{AccessToken: "token", UserID: userID, ExpiredAt: time.Now().Add(time.Hour)})
+		})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if session, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "token"}); err != nil || session.ID != userID {
+			t.Errorf("session should be committed: %v, %v", session, err)
+		}
+	})
+}
+
+func TestQuerier_memory(t *testing.T) {
+	testQuerier(t, func(t *testing.T) database.Querier {
+		return memory.NewQuerier()
+	})
+}
+
+// キャッシュを挟んでも結果が変わらないことを確認する
+func TestQuerier_cache(t *testing.T) {
+	testQuerier(t, func(t *testing.T) database.Querier {
+		return cache.NewQuerier(memory.NewQuerier(), cache.NewLRU(100))
+	})
+}
+
+func articleIDs(articles []model.Article) []int64 {
+	ids := make([]int64, 0, len(articles))
+	for _, a := range articles {
+		ids = append(ids, a.ID)
+	}
+	return sortIDs(ids)
+}
+
+func sortIDs(ids []int64) []int64 {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
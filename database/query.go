@@ -4,22 +4,92 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"reflect"
 	"time"
 
 	"sample-grpc-server/database/model"
+	"sample-grpc-server/logger"
 
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
 	"golang.org/x/xerrors"
 )
 
 type Query struct {
-	db *bun.DB
+	db       bun.IDB
+	replicas *ReplicaSet
+	loc      *time.Location
+
+	// inTx はWithTxの中で使われるQueryであることを表す
+	inTx bool
+}
+
+type QueryOption func(*Query)
+
+// WithReplicas はGetArticles, GetArticleをレプリカで実行する
+func WithReplicas(r *ReplicaSet) QueryOption {
+	return func(q *Query) {
+		q.replicas = r
+	}
 }
 
-func NewQuery(db *bun.DB) *Query {
-	return &Query{
+// WithLocation は取得した日時をlocのタイムゾーンで返す
+func WithLocation(loc *time.Location) QueryOption {
+	return func(q *Query) {
+		q.loc = loc
+	}
+}
+
+func NewQuery(db *bun.DB, opts ...QueryOption) *Query {
+	q := &Query{
 		db: db,
 	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	return q
+}
+
+// read はfnをuserIDに割り当てたレプリカで実行する。使えるレプリカがない場合はプライマリで実行する。
+// レプリカで失敗した場合や、遅延のために見つからなかった場合もプライマリで実行し直す
+func (q *Query) read(ctx context.Context, userID int64, fn func(db bun.IDB) error) error {
+	// トランザクションの中では書き込んだ内容が見えるよう同じトランザクションで読む
+	if q.replicas == nil || q.inTx {
+		return fn(q.db)
+	}
+
+	rep := q.replicas.pick(userID)
+	if rep == nil {
+		return fn(q.db)
+	}
+
+	err := fn(rep.db)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		// 次のヘルスチェックで接続できれば元に戻る
+		logger.Warnf("failed to read from replica, falling back to the primary: %v", err)
+		rep.healthy.Store(false)
+	}
+
+	return fn(q.db)
+}
+
+// localize はdestに取得した日時をWithLocationで指定したタイムゾーンに変換する
+func (q *Query) localize(dest interface{}) {
+	if q.loc != nil {
+		localize(reflect.ValueOf(dest), q.loc)
+	}
+}
+
+// wrote はuserIDの直後の読み込みがプライマリに送られるよう記録する
+func (q *Query) wrote(userID int64) {
+	if q.replicas != nil {
+		q.replicas.MarkWrite(userID)
+	}
 }
 
 type SignUpParams struct {
@@ -37,18 +107,13 @@ func (q *Query) SignUp(ctx context.Context, p SignUpParams) (*SignUpResult, erro
 		Password: p.Password,
 	}
 
-	result, err := q.db.NewInsert().Model(&user).Exec(ctx)
-	if err != nil {
+	// PostgreSQLはLastInsertIdに対応していないため、bunがモデルに設定したIDを使う
+	if _, err := q.db.NewInsert().Model(&user).Exec(ctx); err != nil {
 		return nil, xerrors.Errorf("新規ユーザー登録: %w", err)
 	}
 
-	userID, err := result.LastInsertId()
-	if err != nil {
-		return nil, xerrors.Errorf("failed to get last inserted id: %v", err)
-	}
-
 	return &SignUpResult{
-		UserID: userID,
+		UserID: user.ID,
 	}, nil
 }
 
@@ -86,17 +151,18 @@ func (q *Query) Login(ctx context.Context, p LoginParams) (*LoginResult, error)
 type CreateSessionParams struct {
 	AccessToken string
 	UserID      int64
+	ExpiredAt   time.Time
 }
 
 func (q *Query) CreateSession(ctx context.Context, p CreateSessionParams) error {
 	session := model.Session{
 		AccessToken: p.AccessToken,
 		UserID:      p.UserID,
-		ExpiredAt:   time.Now().Add(time.Hour * 24),
+		ExpiredAt:   p.ExpiredAt,
 	}
 
 	if _, err := q.db.NewInsert().Model(&session).Exec(ctx); err != nil {
-		return xerrors.Errorf("failed to create session: %v", err)
+		return xerrors.Errorf("failed to create session: %w", err)
 	}
 
 	return nil
@@ -107,13 +173,15 @@ type GetSessionParams struct {
 }
 
 type GetSessionResult struct {
-	ID int64
+	ID        int64
+	ExpiredAt time.Time
 }
 
 func (q *Query) GetSession(ctx context.Context, p GetSessionParams) (*GetSessionResult, error) {
 	session := new(model.Session)
 
-	err := q.db.NewSelect().Column("user_id").
+	// ログアウトで削除したセッションが遅延したレプリカで見つからないよう、認証は常にプライマリで確認する
+	err := q.db.NewSelect().Column("user_id", "expired_at").
 		Table("sessions").
 		Where("access_token = ?", p.AccessToken).
 		Where("expired_at > CURRENT_TIMESTAMP").
@@ -126,7 +194,75 @@ func (q *Query) GetSession(ctx context.Context, p GetSessionParams) (*GetSession
 		return nil, xerrors.Errorf("failed to get session: %v", err)
 	}
 
-	return &GetSessionResult{ID: session.UserID}, nil
+	q.localize(session)
+
+	return &GetSessionResult{ID: session.UserID, ExpiredAt: session.ExpiredAt}, nil
+}
+
+type DeleteSessionParams struct {
+	AccessToken string
+}
+
+func (q *Query) DeleteSession(ctx context.Context, p DeleteSessionParams) error {
+	_, err := q.db.NewDelete().
+		Table("sessions").
+		Where("access_token = ?", p.AccessToken).
+		Exec(ctx)
+
+	if err != nil {
+		return xerrors.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+type GetUserTimezoneParams struct {
+	UserID int64
+}
+
+type GetUserTimezoneResult struct {
+	Timezone string
+}
+
+func (q *Query) GetUserTimezone(ctx context.Context, p GetUserTimezoneParams) (*GetUserTimezoneResult, error) {
+	user := new(model.User)
+
+	err := q.db.NewSelect().
+		ColumnExpr("timezone").
+		TableExpr("users").
+		Where("id = ?", p.UserID).
+		Where("deleted_at IS NULL").
+		Scan(ctx, user)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, xerrors.Errorf("failed to get timezone: %w", err)
+		}
+		return nil, xerrors.Errorf("failed to get timezone: %v", err)
+	}
+
+	return &GetUserTimezoneResult{Timezone: user.Timezone}, nil
+}
+
+type UpdateUserTimezoneParams struct {
+	UserID   int64
+	Timezone string
+}
+
+func (q *Query) UpdateUserTimezone(ctx context.Context, p UpdateUserTimezoneParams) error {
+	_, err := q.db.NewUpdate().
+		Table("users").
+		Set("timezone = ?", p.Timezone).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", p.UserID).
+		Where("deleted_at IS NULL").
+		Exec(ctx)
+
+	if err != nil {
+		return xerrors.Errorf("failed to update timezone: %w", err)
+	}
+
+	return nil
 }
 
 type CreateArticleParams struct {
@@ -141,6 +277,8 @@ type CreateArticleResult struct {
 }
 
 func (q *Query) CreateArticle(ctx context.Context, p CreateArticleParams) (*CreateArticleResult, error) {
+	defer q.wrote(p.UserID)
+
 	article := model.Article{
 		UserID:      p.UserID,
 		Title:       p.Title,
@@ -148,21 +286,51 @@ func (q *Query) CreateArticle(ctx context.Context, p CreateArticleParams) (*Crea
 		Text:        p.Text,
 	}
 
-	result, err := q.db.NewInsert().Model(&article).Exec(ctx)
-	if err != nil {
-		return nil, xerrors.Errorf("failed to create article: %v", err)
+	if _, err := q.db.NewInsert().Model(&article).Exec(ctx); err != nil {
+		return nil, xerrors.Errorf("failed to create article: %w", err)
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return nil, xerrors.Errorf("failed to get last inserted id: %v", err)
-	}
+	return &CreateArticleResult{ArticleID: article.ID}, nil
+}
+
+// articleColumns は記事を取得するときにSELECTするカラム
+var articleColumns = []string{"id", "title", "description", "text", "created_at", "updated_at", "deleted_at"}
 
-	return &CreateArticleResult{ArticleID: id}, nil
+// ArticleSort は記事一覧の並び順に使うカラム
+type ArticleSort int
+
+const (
+	ArticleSortCreatedAt ArticleSort = iota
+	ArticleSortUpdatedAt
+	ArticleSortTitle
+)
+
+func (s ArticleSort) column() string {
+	switch s {
+	case ArticleSortUpdatedAt:
+		return "updated_at"
+	case ArticleSortTitle:
+		return "title"
+	default:
+		return "created_at"
+	}
 }
 
 type GetArticlesParams struct {
 	UserID int64
+	// 指定がなければ作成日時の降順
+	SortBy    ArticleSort
+	Ascending bool
+}
+
+// orderExprs は並び順が同じ記事もIDで順番が決まるようにORDER BYの式を返す
+func (p GetArticlesParams) orderExprs() []string {
+	dir := "DESC"
+	if p.Ascending {
+		dir = "ASC"
+	}
+
+	return []string{p.SortBy.column() + " " + dir, "id " + dir}
 }
 
 type GetArticlesResult struct {
@@ -172,21 +340,23 @@ type GetArticlesResult struct {
 func (q *Query) GetArticles(ctx context.Context, p GetArticlesParams) (*GetArticlesResult, error) {
 	var articles []model.Article
 
-	err := q.db.NewSelect().
-		Column("id").
-		Column("title").
-		Column("description").
-		Column("text").
-		Table("articles").
-		Where("user_id = ?", p.UserID).
-		Where("deleted_at IS NULL").
-		Order("created_at DESC").
-		Scan(ctx, &articles)
+	err := q.read(ctx, p.UserID, func(db bun.IDB) error {
+		articles = nil
+		return db.NewSelect().
+			Column(articleColumns...).
+			Table("articles").
+			Where("user_id = ?", p.UserID).
+			Where("deleted_at IS NULL").
+			Order(p.orderExprs()...).
+			Scan(ctx, &articles)
+	})
 
 	if err != nil {
 		return nil, xerrors.Errorf("failed to get articles: %v", err)
 	}
 
+	q.localize(articles)
+
 	return &GetArticlesResult{Articles: articles}, nil
 }
 
@@ -202,17 +372,16 @@ type GetArticleResult struct {
 func (q *Query) GetArticle(ctx context.Context, p GetArticleParams) (*GetArticleResult, error) {
 	var article model.Article
 
-	err := q.db.NewSelect().
-		Column("id").
-		Column("title").
-		Column("description").
-		Column("text").
-		Table("articles").
-		Where("id = ?", p.ArticleID).
-		Where("user_id = ?", p.UserID).
-		Where("deleted_at IS NULL").
-		Limit(1).
-		Scan(ctx, &article)
+	err := q.read(ctx, p.UserID, func(db bun.IDB) error {
+		return db.NewSelect().
+			Column(articleColumns...).
+			Table("articles").
+			Where("id = ?", p.ArticleID).
+			Where("user_id = ?", p.UserID).
+			Where("deleted_at IS NULL").
+			Limit(1).
+			Scan(ctx, &article)
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, xerrors.Errorf("article not found: %w", err)
@@ -220,6 +389,8 @@ func (q *Query) GetArticle(ctx context.Context, p GetArticleParams) (*GetArticle
 		return nil, xerrors.Errorf("failed to get articles: %v", err)
 	}
 
+	q.localize(&article)
+
 	return &GetArticleResult{Article: article}, nil
 }
 
@@ -232,6 +403,8 @@ type UpdateArticleParams struct {
 }
 
 func (q *Query) UpdateArticle(ctx context.Context, p UpdateArticleParams) error {
+	defer q.wrote(p.UserID)
+
 	_, err := q.db.NewUpdate().
 		Table("articles").
 		Set("title = ?", p.Title).
@@ -244,7 +417,33 @@ func (q *Query) UpdateArticle(ctx context.Context, p UpdateArticleParams) error
 		Exec(ctx)
 
 	if err != nil {
-		return xerrors.Errorf("failed to update article: %v", err)
+		return xerrors.Errorf("failed to update article: %w", err)
+	}
+
+	return nil
+}
+
+type UpdateArticleTextParams struct {
+	ArticleID int64
+	UserID    int64
+	Text      string
+}
+
+// UpdateArticleText は本文だけを更新する。タイトルや説明は同時に行われた更新を上書きしないよう変更しない
+func (q *Query) UpdateArticleText(ctx context.Context, p UpdateArticleTextParams) error {
+	defer q.wrote(p.UserID)
+
+	_, err := q.db.NewUpdate().
+		Table("articles").
+		Set("text = ?", p.Text).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", p.ArticleID).
+		Where("user_id = ?", p.UserID).
+		Returning("NULL").
+		Exec(ctx)
+
+	if err != nil {
+		return xerrors.Errorf("failed to update article text: %w", err)
 	}
 
 	return nil
@@ -256,6 +455,8 @@ type DeleteArticleParams struct {
 }
 
 func (q *Query) DeleteArticle(ctx context.Context, p DeleteArticleParams) error {
+	defer q.wrote(p.UserID)
+
 	_, err := q.db.NewUpdate().
 		Table("articles").
 		Set("deleted_at = ?", time.Now()).
@@ -265,7 +466,329 @@ func (q *Query) DeleteArticle(ctx context.Context, p DeleteArticleParams) error
 		Exec(ctx)
 
 	if err != nil {
-		return xerrors.Errorf("failed to delete article: %v", err)
+		return xerrors.Errorf("failed to delete article: %w", err)
+	}
+
+	return nil
+}
+
+type BatchCreateArticle struct {
+	ExternalID  sql.NullString
+	Title       string
+	Description sql.NullString
+	Text        string
+}
+
+type BatchCreateArticlesParams struct {
+	UserID   int64
+	Articles []BatchCreateArticle
+}
+
+type BatchCreateArticlesResult struct {
+	ArticleIDs []int64
+}
+
+func (q *Query) BatchCreateArticles(ctx context.Context, p BatchCreateArticlesParams) (*BatchCreateArticlesResult, error) {
+	defer q.wrote(p.UserID)
+
+	if len(p.Articles) == 0 {
+		return &BatchCreateArticlesResult{}, nil
+	}
+
+	articles := make([]model.Article, 0, len(p.Articles))
+	for _, a := range p.Articles {
+		articles = append(articles, model.Article{
+			UserID:      p.UserID,
+			ExternalID:  a.ExternalID,
+			Title:       a.Title,
+			Description: a.Description,
+			Text:        a.Text,
+		})
+	}
+
+	err := q.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(&articles).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create articles: %w", err)
+	}
+
+	ids := make([]int64, 0, len(articles))
+	for _, a := range articles {
+		ids = append(ids, a.ID)
+	}
+
+	return &BatchCreateArticlesResult{ArticleIDs: ids}, nil
+}
+
+type BatchGetArticlesParams struct {
+	ArticleIDs []int64
+	UserID     int64
+}
+
+type BatchGetArticlesResult struct {
+	Articles []model.Article
+}
+
+func (q *Query) BatchGetArticles(ctx context.Context, p BatchGetArticlesParams) (*BatchGetArticlesResult, error) {
+	var articles []model.Article
+
+	if len(p.ArticleIDs) == 0 {
+		return &BatchGetArticlesResult{}, nil
+	}
+
+	err := q.db.NewSelect().
+		Column(articleColumns...).
+		Table("articles").
+		Where("id IN (?)", bun.In(p.ArticleIDs)).
+		Where("user_id = ?", p.UserID).
+		Where("deleted_at IS NULL").
+		Scan(ctx, &articles)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get articles: %v", err)
+	}
+
+	q.localize(articles)
+
+	return &BatchGetArticlesResult{Articles: articles}, nil
+}
+
+type BatchDeleteArticlesParams struct {
+	ArticleIDs   []int64
+	UserID       int64
+	AllOrNothing bool
+}
+
+type BatchDeleteArticlesResult struct {
+	DeletedIDs []int64
+	MissingIDs []int64
+}
+
+func (q *Query) BatchDeleteArticles(ctx context.Context, p BatchDeleteArticlesParams) (*BatchDeleteArticlesResult, error) {
+	defer q.wrote(p.UserID)
+
+	result := &BatchDeleteArticlesResult{}
+
+	if len(p.ArticleIDs) == 0 {
+		return result, nil
+	}
+
+	err := q.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var found []int64
+
+		err := tx.NewSelect().
+			Column("id").
+			Table("articles").
+			Where("id IN (?)", bun.In(p.ArticleIDs)).
+			Where("user_id = ?", p.UserID).
+			Where("deleted_at IS NULL").
+			Apply(forUpdate).
+			Scan(ctx, &found)
+		if err != nil {
+			return err
+		}
+
+		exists := make(map[int64]bool, len(found))
+		for _, id := range found {
+			exists[id] = true
+		}
+
+		for _, id := range p.ArticleIDs {
+			if !exists[id] {
+				result.MissingIDs = append(result.MissingIDs, id)
+			}
+		}
+
+		if len(found) == 0 || (p.AllOrNothing && len(result.MissingIDs) > 0) {
+			return nil
+		}
+
+		_, err = tx.NewUpdate().
+			Table("articles").
+			Set("deleted_at = ?", time.Now()).
+			Where("id IN (?)", bun.In(found)).
+			Where("user_id = ?", p.UserID).
+			Returning("NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		result.DeletedIDs = found
+
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to delete articles: %w", err)
+	}
+
+	return result, nil
+}
+
+// forUpdate は読み込んだ行をトランザクションが終わるまでロックする。
+// SQLiteはFOR UPDATEに対応していないが、トランザクションの開始時にデータベース全体の書き込みロックを取るため不要
+func forUpdate(q *bun.SelectQuery) *bun.SelectQuery {
+	if q.Dialect().Name() == dialect.SQLite {
+		return q
+	}
+	return q.For("UPDATE")
+}
+
+type GetArticleExternalIDsParams struct {
+	UserID      int64
+	ExternalIDs []string
+}
+
+type GetArticleExternalIDsResult struct {
+	ExternalIDs []string
+}
+
+// 削除済みの記事も一意制約の対象になるため、deleted_atに関わらず取得する
+func (q *Query) GetArticleExternalIDs(ctx context.Context, p GetArticleExternalIDsParams) (*GetArticleExternalIDsResult, error) {
+	var ids []string
+
+	if len(p.ExternalIDs) == 0 {
+		return &GetArticleExternalIDsResult{}, nil
+	}
+
+	err := q.db.NewSelect().
+		Column("external_id").
+		Table("articles").
+		Where("user_id = ?", p.UserID).
+		Where("external_id IN (?)", bun.In(p.ExternalIDs)).
+		Scan(ctx, &ids)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get external ids: %v", err)
+	}
+
+	return &GetArticleExternalIDsResult{ExternalIDs: ids}, nil
+}
+
+type CreateAttachmentParams struct {
+	ArticleID   int64
+	UserID      int64
+	Filename    string
+	ContentType string
+	Size        int64
+	SHA256      string
+	StorageKey  string
+}
+
+type CreateAttachmentResult struct {
+	AttachmentID int64
+	CreatedAt    time.Time
+}
+
+func (q *Query) CreateAttachment(ctx context.Context, p CreateAttachmentParams) (*CreateAttachmentResult, error) {
+	attachment := model.Attachment{
+		ArticleID:   p.ArticleID,
+		UserID:      p.UserID,
+		Filename:    p.Filename,
+		ContentType: p.ContentType,
+		Size:        p.Size,
+		SHA256:      p.SHA256,
+		StorageKey:  p.StorageKey,
+		CreatedAt:   time.Now(),
+	}
+
+	if _, err := q.db.NewInsert().Model(&attachment).Exec(ctx); err != nil {
+		return nil, xerrors.Errorf("failed to create attachment: %w", err)
+	}
+
+	return &CreateAttachmentResult{AttachmentID: attachment.ID, CreatedAt: attachment.CreatedAt}, nil
+}
+
+type GetAttachmentParams struct {
+	AttachmentID int64
+	UserID       int64
+}
+
+type GetAttachmentResult struct {
+	Attachment model.Attachment
+}
+
+func (q *Query) GetAttachment(ctx context.Context, p GetAttachmentParams) (*GetAttachmentResult, error) {
+	var attachment model.Attachment
+
+	err := q.db.NewSelect().
+		ColumnExpr("at.*").
+		Model(&attachment).
+		Relation("Thumbnails").
+		Join("JOIN articles AS a ON a.id = at.article_id").
+		Where("at.id = ?", p.AttachmentID).
+		Where("at.user_id = ?", p.UserID).
+		Where("a.deleted_at IS NULL").
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, xerrors.Errorf("attachment not found: %w", err)
+		}
+		return nil, xerrors.Errorf("failed to get attachment: %v", err)
+	}
+
+	q.localize(&attachment)
+
+	return &GetAttachmentResult{Attachment: attachment}, nil
+}
+
+type GetArticleAttachmentsParams struct {
+	ArticleIDs []int64
+	UserID     int64
+}
+
+type GetArticleAttachmentsResult struct {
+	Attachments []model.Attachment
+}
+
+func (q *Query) GetArticleAttachments(ctx context.Context, p GetArticleAttachmentsParams) (*GetArticleAttachmentsResult, error) {
+	var attachments []model.Attachment
+
+	if len(p.ArticleIDs) == 0 {
+		return &GetArticleAttachmentsResult{}, nil
+	}
+
+	err := q.db.NewSelect().
+		Model(&attachments).
+		Relation("Thumbnails", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("th.size ASC")
+		}).
+		Where("at.article_id IN (?)", bun.In(p.ArticleIDs)).
+		Where("at.user_id = ?", p.UserID).
+		Order("at.id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get attachments: %v", err)
+	}
+
+	q.localize(attachments)
+
+	return &GetArticleAttachmentsResult{Attachments: attachments}, nil
+}
+
+type CreateThumbnailParams struct {
+	AttachmentID int64
+	Size         int
+	Width        int
+	Height       int
+	ContentType  string
+	StorageKey   string
+}
+
+func (q *Query) CreateThumbnail(ctx context.Context, p CreateThumbnailParams) error {
+	thumbnail := model.Thumbnail{
+		AttachmentID: p.AttachmentID,
+		Size:         p.Size,
+		Width:        p.Width,
+		Height:       p.Height,
+		ContentType:  p.ContentType,
+		StorageKey:   p.StorageKey,
+		CreatedAt:    time.Now(),
+	}
+
+	if _, err := q.db.NewInsert().Model(&thumbnail).Exec(ctx); err != nil {
+		return xerrors.Errorf("failed to create thumbnail: %w", err)
 	}
 
 	return nil
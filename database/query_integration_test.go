@@ -0,0 +1,28 @@
+//go:build integration
+
+package database_test
+
+import (
+	"os"
+	"testing"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/database/cache"
+	"sample-grpc-server/database/dbtest"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(dbtest.Run(m))
+}
+
+func TestQuery(t *testing.T) {
+	testQuerier(t, func(t *testing.T) database.Querier {
+		return database.NewQuery(dbtest.Open(t))
+	})
+}
+
+func TestQuery_cache(t *testing.T) {
+	testQuerier(t, func(t *testing.T) database.Querier {
+		return cache.NewQuerier(database.NewQuery(dbtest.Open(t)), cache.NewLRU(100))
+	})
+}
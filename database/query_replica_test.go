@@ -0,0 +1,43 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/database/dbtest"
+
+	"github.com/uptrace/bun"
+)
+
+func TestQuery_GetSession_replica(t *testing.T) {
+	ctx := context.Background()
+	primary, replica := dbtest.OpenSQLite(t), dbtest.OpenSQLite(t)
+
+	// 削除がまだ届いていないレプリカとして、プライマリと同じユーザーとセッションを作成しておく
+	for _, db := range []*bun.DB{primary, replica} {
+		q := database.NewQuery(db)
+		user, err := q.SignUp(ctx, database.SignUpParams{Email: "test@example.com", Password: "hash"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := q.CreateSession(ctx, database.CreateSessionParams{AccessToken: "token", UserID: user.UserID, ExpiredAt: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q := database.NewQuery(primary, database.WithReplicas(database.NewReplicaSet([]*bun.DB{replica}, time.Minute)))
+
+	if _, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "token"}); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if err := q.DeleteSession(ctx, database.DeleteSessionParams{AccessToken: "token"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.GetSession(ctx, database.GetSessionParams{AccessToken: "token"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("revoked session should not be found: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sample-grpc-server/logger"
+
+	"github.com/uptrace/bun"
+)
+
+// ReplicaSet は読み込みを振り分けるレプリカと、直前に書き込んだユーザーを管理する。
+// 書き込んだユーザーの読み込みはレプリカの遅延で古い値が見えないよう、しばらくプライマリに送る
+type ReplicaSet struct {
+	replicas []*replica
+	window   time.Duration
+	next     atomic.Uint64
+
+	mu         sync.Mutex
+	lastWrites map[int64]time.Time
+}
+
+type replica struct {
+	db      *bun.DB
+	healthy atomic.Bool
+}
+
+func NewReplicaSet(dbs []*bun.DB, window time.Duration) *ReplicaSet {
+	r := &ReplicaSet{
+		window:     window,
+		lastWrites: make(map[int64]time.Time),
+	}
+
+	for _, db := range dbs {
+		rep := &replica{db: db}
+		rep.healthy.Store(true)
+		r.replicas = append(r.replicas, rep)
+	}
+
+	return r
+}
+
+// MarkWrite はuserIDが書き込んだことを記録する
+func (r *ReplicaSet) MarkWrite(userID int64) {
+	if r.window <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastWrites[userID] = time.Now()
+}
+
+// pick はuserIDの読み込みに使うレプリカを順番に返す。プライマリを使うべき場合はnilを返す
+func (r *ReplicaSet) pick(userID int64) *replica {
+	if userID != 0 && r.sticky(userID) {
+		return nil
+	}
+
+	n := len(r.replicas)
+	if n == 0 {
+		return nil
+	}
+	start := int(r.next.Add(1) % uint64(n))
+	for i := 0; i < n; i++ {
+		rep := r.replicas[(start+i)%n]
+		if rep.healthy.Load() {
+			return rep
+		}
+	}
+
+	return nil
+}
+
+func (r *ReplicaSet) sticky(userID int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.lastWrites[userID]
+	return ok && time.Since(t) < r.window
+}
+
+// Run はctxがキャンセルされるまでintervalごとにレプリカに接続できるかを確認する
+func (r *ReplicaSet) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check はレプリカの状態を更新し、期限の切れた書き込みの記録を捨てる
+func (r *ReplicaSet) Check(ctx context.Context) {
+	for i, rep := range r.replicas {
+		err := ping(ctx, rep.db.DB)
+		if ctx.Err() != nil {
+			return
+		}
+
+		healthy := err == nil
+		if rep.healthy.Swap(healthy) != healthy {
+			if healthy {
+				logger.Infof("replica #%d is reachable again", i)
+			} else {
+				logger.Warnf("replica #%d is unreachable, reading from the primary: %v", i, err)
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for userID, t := range r.lastWrites {
+		if time.Since(t) >= r.window {
+			delete(r.lastWrites, userID)
+		}
+	}
+}
+
+func (r *ReplicaSet) Close() error {
+	var firstErr error
+	for _, rep := range r.replicas {
+		if err := rep.db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
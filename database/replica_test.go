@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/mysqldialect"
+)
+
+func newFakeReplica(t *testing.T, conn *fakeConnector) *bun.DB {
+	t.Helper()
+
+	sqlDB := sql.OpenDB(conn)
+	sqlDB.SetMaxIdleConns(0)
+	db := bun.NewDB(sqlDB, mysqldialect.New())
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestReplicaSet_pick(t *testing.T) {
+	conn1, conn2 := &fakeConnector{}, &fakeConnector{}
+	db1, db2 := newFakeReplica(t, conn1), newFakeReplica(t, conn2)
+
+	r := NewReplicaSet([]*bun.DB{db1, db2}, time.Hour)
+
+	t.Run("順番に振り分ける", func(t *testing.T) {
+		a, b := r.pick(1), r.pick(1)
+		if a == nil || b == nil || a == b {
+			t.Errorf("replicas should be used in turn: %p %p", a, b)
+		}
+	})
+
+	t.Run("書き込んだユーザーはプライマリを使う", func(t *testing.T) {
+		r.MarkWrite(1)
+
+		if r.pick(1) != nil {
+			t.Error("user who wrote should read from the primary")
+		}
+		if r.pick(2) == nil {
+			t.Error("other users should read from replicas")
+		}
+	})
+
+	t.Run("接続できないレプリカは使わない", func(t *testing.T) {
+		conn1.down.Store(true)
+		r.Check(context.Background())
+
+		for i := 0; i < 4; i++ {
+			if rep := r.pick(2); rep == nil || rep.db != db2 {
+				t.Fatalf("healthy replica should be used: %v", rep)
+			}
+		}
+
+		conn2.down.Store(true)
+		r.Check(context.Background())
+		if r.pick(2) != nil {
+			t.Error("primary should be used when all replicas are down")
+		}
+	})
+}
+
+func TestReplicaSet_Check(t *testing.T) {
+	r := NewReplicaSet(nil, time.Millisecond)
+	r.MarkWrite(1)
+
+	time.Sleep(5 * time.Millisecond)
+	r.Check(context.Background())
+
+	if len(r.lastWrites) != 0 {
+		t.Errorf("expired writes should be removed: %v", r.lastWrites)
+	}
+}
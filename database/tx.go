@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"sample-grpc-server/logger"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	maxTxAttempts = 3
+	txRetryDelay  = 20 * time.Millisecond
+)
+
+// WithTx はfnに渡すQuerierのクエリを1つのトランザクションで実行し、fnがエラーを返した場合はロールバックする。
+// デッドロックなど再実行すれば成功しうるエラーの場合はfnごと再実行するため、fnはデータベース以外に副作用を持たないようにする。
+// トランザクションの中で呼んだ場合はセーブポイントを使い、再実行は外側のトランザクションに任せる
+func (q *Query) WithTx(ctx context.Context, fn func(Querier) error) error {
+	if q.inTx {
+		return q.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			return fn(q.withTx(tx))
+		})
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := q.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			return fn(q.withTx(tx))
+		})
+		if err == nil || attempt >= maxTxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		logger.Warnf("retrying transaction (attempt %d): %v", attempt, err)
+
+		// 同時に再実行して再びデッドロックしないよう待ち時間をばらつかせる
+		wait := txRetryDelay*time.Duration(attempt) + time.Duration(rand.Int63n(int64(txRetryDelay)))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (q *Query) withTx(tx bun.Tx) *Query {
+	return &Query{
+		db:       tx,
+		replicas: q.replicas,
+		loc:      q.loc,
+		inTx:     true,
+	}
+}
@@ -0,0 +1,205 @@
+package e2e
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"sample-grpc-server/interceptor"
+	"sample-grpc-server/pb"
+	"sample-grpc-server/server"
+	"sample-grpc-server/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// newClient はメモリ上の接続で待ち受けるサーバーを起動し、接続したクライアントを返す
+func newClient(t *testing.T) pb.BackendServiceClient {
+	t.Helper()
+
+	db := newQuerier(t)
+
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(interceptor.AuthInterceptor(db)),
+		grpc.ChainStreamInterceptor(interceptor.AuthStreamInterceptor(db)),
+	)
+	pb.RegisterBackendServiceServer(s, server.NewServer(db, service.NewHash(), service.NewAuth()))
+
+	ln := bufconn.Listen(1 << 20)
+	go s.Serve(ln)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewBackendServiceClient(conn)
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "access_token", token)
+}
+
+func signUp(t *testing.T, c pb.BackendServiceClient, email string) context.Context {
+	t.Helper()
+
+	resp, err := c.SignUp(context.Background(), &pb.SignUpRequest{Email: email, Password: "password"})
+	if err != nil {
+		t.Fatalf("failed to sign up: %v", err)
+	}
+	return withToken(resp.AccessToken)
+}
+
+func expectCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+
+	if status.Code(err) != code {
+		t.Errorf("Expect: %v, Got: %v", code, err)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	c := newClient(t)
+	signUp(t, c, "test@example.com")
+
+	t.Run("登録済みのメールアドレス", func(t *testing.T) {
+		_, err := c.SignUp(context.Background(), &pb.SignUpRequest{Email: "test@example.com", Password: "password"})
+		expectCode(t, err, codes.AlreadyExists)
+	})
+
+	t.Run("ログイン", func(t *testing.T) {
+		resp, err := c.Login(context.Background(), &pb.LoginRequest{Email: "test@example.com", Password: "password"})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if _, err := c.GetArticles(withToken(resp.AccessToken), &pb.GetArticlesRequest{}); err != nil {
+			t.Errorf("issued token should be accepted: %v", err)
+		}
+	})
+
+	t.Run("ログアウト", func(t *testing.T) {
+		resp, err := c.Login(context.Background(), &pb.LoginRequest{Email: "test@example.com", Password: "password"})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		ctx := withToken(resp.AccessToken)
+
+		if _, err := c.Logout(ctx, &emptypb.Empty{}); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		_, err = c.GetArticles(ctx, &pb.GetArticlesRequest{})
+		expectCode(t, err, codes.Unauthenticated)
+	})
+
+	t.Run("パスワードの誤り", func(t *testing.T) {
+		_, err := c.Login(context.Background(), &pb.LoginRequest{Email: "test@example.com", Password: "wrong"})
+		expectCode(t, err, codes.InvalidArgument)
+	})
+
+	t.Run("アクセストークンなし", func(t *testing.T) {
+		_, err := c.GetArticles(context.Background(), &pb.GetArticlesRequest{})
+		expectCode(t, err, codes.Unauthenticated)
+	})
+
+	t.Run("不正なアクセストークン", func(t *testing.T) {
+		_, err := c.GetArticles(withToken("invalid"), &pb.GetArticlesRequest{})
+		expectCode(t, err, codes.Unauthenticated)
+	})
+}
+
+func TestArticle(t *testing.T) {
+	c := newClient(t)
+	ctx := signUp(t, c, "test@example.com")
+	other := signUp(t, c, "other@example.com")
+
+	description := "description"
+	created, err := c.CreateArticle(ctx, &pb.CreateArticleRequest{Title: "title", Description: &description, Text: "text"})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	id := created.ArticleId
+
+	got, err := c.GetArticle(ctx, &pb.GetArticleRequest{ArticleId: id})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if got.Article.Title != "title" || got.Article.GetDescription() != "description" || got.Article.Text != "text" {
+		t.Errorf("unexpected article: %v", got.Article)
+	}
+	if got.Article.CreatedAt.AsTime().IsZero() || got.Article.UpdatedAt.AsTime().IsZero() || got.Article.DeletedAt != nil {
+		t.Errorf("unexpected timestamps: %v", got.Article)
+	}
+
+	_, err = c.GetArticle(other, &pb.GetArticleRequest{ArticleId: id})
+	expectCode(t, err, codes.NotFound)
+
+	if _, err := c.UpdateArticle(ctx, &pb.UpdateArticleRequest{ArticleId: id, Title: "updated", Text: "updated text"}); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	list, err := c.GetArticles(ctx, &pb.GetArticlesRequest{})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if len(list.Articles) != 1 || list.Articles[0].Title != "updated" || list.Articles[0].Text != "updated text" {
+		t.Errorf("article should be updated: %v", list.Articles)
+	}
+
+	if _, err := c.DeleteArticle(ctx, &pb.DeleteArticleRequest{ArticleId: id}); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	_, err = c.GetArticle(ctx, &pb.GetArticleRequest{ArticleId: id})
+	expectCode(t, err, codes.NotFound)
+
+	list, err = c.GetArticles(ctx, &pb.GetArticlesRequest{})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if len(list.Articles) != 0 {
+		t.Errorf("deleted article should not be listed: %v", list.Articles)
+	}
+}
+
+func TestUserSettings(t *testing.T) {
+	c := newClient(t)
+	ctx := signUp(t, c, "test@example.com")
+
+	settings, err := c.GetUserSettings(ctx, &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if settings.Timezone != "UTC" {
+		t.Errorf("Expect: %v, Got: %v", "UTC", settings.Timezone)
+	}
+
+	if _, err := c.UpdateUserSettings(ctx, &pb.UpdateUserSettingsRequest{Timezone: "Asia/Tokyo"}); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	settings, err = c.GetUserSettings(ctx, &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if settings.Timezone != "Asia/Tokyo" {
+		t.Errorf("Expect: %v, Got: %v", "Asia/Tokyo", settings.Timezone)
+	}
+
+	_, err = c.UpdateUserSettings(ctx, &pb.UpdateUserSettingsRequest{Timezone: "Mars/Olympus"})
+	expectCode(t, err, codes.InvalidArgument)
+}
@@ -0,0 +1,19 @@
+//go:build integration
+
+package e2e
+
+import (
+	"os"
+	"testing"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/database/dbtest"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(dbtest.Run(m))
+}
+
+func newQuerier(t *testing.T) database.Querier {
+	return database.NewQuery(dbtest.Open(t))
+}
@@ -0,0 +1,15 @@
+//go:build !integration
+
+package e2e
+
+import (
+	"testing"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/database/memory"
+)
+
+// newQuerier は通常のテストではメモリ上のQuerierを使う。integrationタグを付けるとデータベースに接続する
+func newQuerier(t *testing.T) database.Querier {
+	return memory.NewQuerier()
+}
@@ -0,0 +1,123 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"sample-grpc-server/database/model"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+)
+
+const ManifestName = "manifest.jsonl"
+
+type frontMatter struct {
+	Title       string    `yaml:"title"`
+	Description *string   `yaml:"description,omitempty"`
+	CreatedAt   time.Time `yaml:"created_at"`
+}
+
+type manifestEntry struct {
+	ID          int64     `json:"id"`
+	File        string    `json:"file"`
+	Title       string    `json:"title"`
+	Description *string   `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WriteArchive は記事ごとのMarkdownファイルとマニフェストを含むZIPをwに書き込む。
+// 日時はlocのタイムゾーンで出力する
+func WriteArchive(w io.Writer, articles []model.Article, loc *time.Location) error {
+	zw := zip.NewWriter(w)
+
+	var manifest bytes.Buffer
+	enc := json.NewEncoder(&manifest)
+
+	for _, article := range articles {
+		name := fmt.Sprintf("articles/%d.md", article.ID)
+		article.CreatedAt = article.CreatedAt.In(loc)
+
+		var description *string
+		if article.Description.Valid {
+			description = &article.Description.String
+		}
+
+		md, err := Markdown(article)
+		if err != nil {
+			return err
+		}
+
+		if err := writeFile(zw, name, article.CreatedAt, md); err != nil {
+			return err
+		}
+
+		if err := enc.Encode(manifestEntry{
+			ID:          article.ID,
+			File:        name,
+			Title:       article.Title,
+			Description: description,
+			CreatedAt:   article.CreatedAt,
+		}); err != nil {
+			return xerrors.Errorf("failed to encode manifest: %v", err)
+		}
+	}
+
+	if err := writeFile(zw, ManifestName, time.Now().In(loc), manifest.Bytes()); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return xerrors.Errorf("failed to close archive: %w", err)
+	}
+
+	return nil
+}
+
+// Markdown はYAMLのフロントマターに続けて本文を出力する
+func Markdown(article model.Article) ([]byte, error) {
+	fm := frontMatter{
+		Title:     article.Title,
+		CreatedAt: article.CreatedAt,
+	}
+	if article.Description.Valid {
+		fm.Description = &article.Description.String
+	}
+
+	header, err := yaml.Marshal(fm)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode front matter: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(header)
+	buf.WriteString("---\n\n")
+	buf.WriteString(article.Text)
+	if n := len(article.Text); n > 0 && article.Text[n-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeFile(zw *zip.Writer, name string, modified time.Time, body []byte) error {
+	fw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return xerrors.Errorf("failed to create %s: %w", name, err)
+	}
+
+	if _, err := fw.Write(body); err != nil {
+		return xerrors.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
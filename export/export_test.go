@@ -0,0 +1,108 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"sample-grpc-server/database/model"
+)
+
+func TestMarkdown(t *testing.T) {
+	article := model.Article{
+		ID:          1,
+		Title:       "title: with colon",
+		Description: sql.NullString{String: "desc", Valid: true},
+		Text:        "# heading",
+		CreatedAt:   time.Date(2023, 3, 29, 0, 0, 0, 0, time.UTC),
+	}
+
+	got, err := Markdown(article)
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	expect := "---\n" +
+		"title: 'title: with colon'\n" +
+		"description: desc\n" +
+		"created_at: 2023-03-29T00:00:00Z\n" +
+		"---\n\n" +
+		"# heading\n"
+
+	if string(got) != expect {
+		t.Errorf("Expect: %q, Got: %q", expect, string(got))
+	}
+}
+
+func TestWriteArchive(t *testing.T) {
+	createdAt := time.Date(2023, 3, 29, 0, 0, 0, 0, time.UTC)
+	articles := []model.Article{
+		{ID: 1, Title: "title1", Text: "text1", CreatedAt: createdAt},
+		{ID: 2, Title: "title2", Text: "text2", CreatedAt: createdAt},
+	}
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteArchive(&buf, articles, tokyo); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+
+	expect := "articles/1.md,articles/2.md," + ManifestName
+	if got := strings.Join(names, ","); got != expect {
+		t.Errorf("Expect: %v, Got: %v", expect, got)
+	}
+
+	f, err := zr.Open(ManifestName)
+	if err != nil {
+		t.Fatalf("failed to open manifest: %v", err)
+	}
+	defer f.Close()
+
+	var entries []manifestEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e manifestEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("failed to decode manifest: %v", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) != 2 || entries[1].File != "articles/2.md" {
+		t.Errorf("unexpected manifest: %+v", entries)
+	}
+
+	md, err := zr.Open("articles/1.md")
+	if err != nil {
+		t.Fatalf("failed to open article: %v", err)
+	}
+	defer md.Close()
+
+	body, _ := io.ReadAll(md)
+	if !strings.HasSuffix(string(body), "\ntext1\n") {
+		t.Errorf("unexpected body: %q", body)
+	}
+	if !strings.Contains(string(body), "created_at: 2023-03-29T09:00:00+09:00\n") {
+		t.Errorf("created_at should be in the given location: %q", body)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"sample-grpc-server/database"
 
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"golang.org/x/xerrors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -41,11 +42,36 @@ func AuthInterceptor(db database.Querier) grpc.UnaryServerInterceptor {
 	}
 }
 
+func AuthStreamInterceptor(db database.Querier) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isAuthFree(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		newCtx, err := authenticate(ss.Context(), db)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return status.Error(codes.Unauthenticated, "failed to authenticate")
+			} else if errors.Is(err, ErrNoAccessToken) {
+				return status.Error(codes.Unauthenticated, "failed to authenticate")
+			}
+			return status.Error(codes.Internal, "server error")
+		}
+
+		wrapped := grpc_middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = newCtx
+
+		return handler(srv, wrapped)
+	}
+}
+
 func isAuthFree(method string) bool {
 	authFreeMethods := []string{
 		"/backend.BackendService/HelloWorld",
 		"/backend.BackendService/SignUp",
 		"/backend.BackendService/Login",
+		"/grpc.health.v1.Health/Check",
+		"/grpc.health.v1.Health/Watch",
 	}
 
 	for _, m := range authFreeMethods {
@@ -77,6 +103,7 @@ func authenticate(ctx context.Context, db database.Querier) (context.Context, er
 	}
 
 	ctx = context.WithValue(ctx, server.KeyUserID, session.ID)
+	ctx = context.WithValue(ctx, server.KeyAccessToken, tokens[0])
 
 	return ctx, nil
 }
@@ -29,6 +29,11 @@ func Test_isAuthFree(t *testing.T) {
 			args: args{method: "/backend.BackendService/Login"},
 			want: true,
 		},
+		{
+			name: "/grpc.health.v1.Health/Check",
+			args: args{method: "/grpc.health.v1.Health/Check"},
+			want: true,
+		},
 		{
 			name: "/backend.BackendService/GetArticle",
 			args: args{method: "/backend.BackendService/GetArticle"},
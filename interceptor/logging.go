@@ -0,0 +1,44 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"sample-grpc-server/logger"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LoggingInterceptor はRPCの結果と処理時間を記録する。サーバー側の問題はerror、それ以外はdebugで出力する
+func LoggingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logRPC(info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+func LoggingStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logRPC(info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+func logRPC(method string, start time.Time, err error) {
+	code := status.Code(err)
+
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		logger.Errorf("%s %s %s: %v", method, code, time.Since(start), err)
+	default:
+		logger.Debugf("%s %s %s", method, code, time.Since(start))
+	}
+}
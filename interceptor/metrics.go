@@ -0,0 +1,134 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ prometheus.Collector = (*Metrics)(nil)
+
+// Metrics はRPCごとの件数、処理時間、処理中の数、メッセージの大きさを記録する。prometheus.Collectorとして登録して取得する
+type Metrics struct {
+	handled  *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+	msgSize  *prometheus.HistogramVec
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		handled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_handled_total",
+			Help: "Number of RPCs completed on the server, by method and status code.",
+		}, []string{"grpc_type", "grpc_method", "grpc_code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Duration of RPCs until completed by the server.",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"grpc_type", "grpc_method"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "grpc_server_in_flight_requests",
+			Help: "Number of RPCs currently being handled by the server.",
+		}, []string{"grpc_type", "grpc_method"}),
+		msgSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_msg_size_bytes",
+			Help:    "Size of messages received and sent by the server.",
+			Buckets: prometheus.ExponentialBuckets(64, 4, 10),
+		}, []string{"grpc_method", "direction"}),
+	}
+}
+
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.handled.Describe(ch)
+	m.duration.Describe(ch)
+	m.inFlight.Describe(ch)
+	m.msgSize.Describe(ch)
+}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.handled.Collect(ch)
+	m.duration.Collect(ch)
+	m.inFlight.Collect(ch)
+	m.msgSize.Collect(ch)
+}
+
+// start は処理中の数を増やし、RPCが終わったときに呼ぶ関数を返す
+func (m *Metrics) start(typ, method string) func(err error) {
+	inFlight := m.inFlight.WithLabelValues(typ, method)
+	inFlight.Inc()
+
+	start := time.Now()
+
+	return func(err error) {
+		inFlight.Dec()
+		m.duration.WithLabelValues(typ, method).Observe(time.Since(start).Seconds())
+		m.handled.WithLabelValues(typ, method, status.Code(err).String()).Inc()
+	}
+}
+
+func (m *Metrics) observeSize(method, direction string, msg interface{}) {
+	if msg, ok := msg.(proto.Message); ok {
+		m.msgSize.WithLabelValues(method, direction).Observe(float64(proto.Size(msg)))
+	}
+}
+
+func MetricsInterceptor(m *Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		done := m.start("unary", info.FullMethod)
+		m.observeSize(info.FullMethod, "received", req)
+
+		resp, err := handler(ctx, req)
+		if err == nil {
+			m.observeSize(info.FullMethod, "sent", resp)
+		}
+		done(err)
+
+		return resp, err
+	}
+}
+
+func MetricsStreamInterceptor(m *Metrics) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		typ := "bidi_stream"
+		switch {
+		case info.IsClientStream && !info.IsServerStream:
+			typ = "client_stream"
+		case !info.IsClientStream && info.IsServerStream:
+			typ = "server_stream"
+		}
+
+		done := m.start(typ, info.FullMethod)
+		err := handler(srv, &metricsStream{ServerStream: ss, metrics: m, method: info.FullMethod})
+		done(err)
+
+		return err
+	}
+}
+
+// metricsStream はストリームで送受信したメッセージの大きさを記録する
+type metricsStream struct {
+	grpc.ServerStream
+	metrics *Metrics
+	method  string
+}
+
+func (s *metricsStream) RecvMsg(msg interface{}) error {
+	err := s.ServerStream.RecvMsg(msg)
+	if err == nil {
+		s.metrics.observeSize(s.method, "received", msg)
+	}
+	return err
+}
+
+func (s *metricsStream) SendMsg(msg interface{}) error {
+	err := s.ServerStream.SendMsg(msg)
+	if err == nil {
+		s.metrics.observeSize(s.method, "sent", msg)
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"sample-grpc-server/pb"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMetricsInterceptor(t *testing.T) {
+	m := NewMetrics()
+	intercept := MetricsInterceptor(m)
+	info := &grpc.UnaryServerInfo{FullMethod: "/backend.BackendService/Login"}
+
+	intercept(context.Background(), &pb.LoginRequest{Email: "test@example.com"}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		// 処理中の数に含まれる
+		if got := testutil.ToFloat64(m.inFlight.WithLabelValues("unary", info.FullMethod)); got != 1 {
+			t.Errorf("Expect: %v, Got: %v", 1, got)
+		}
+		return &pb.LoginResponse{AccessToken: "token"}, nil
+	})
+	intercept(context.Background(), &pb.LoginRequest{}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.InvalidArgument, "invalid email or password")
+	})
+
+	tests := []struct {
+		name   string
+		got    float64
+		expect float64
+	}{
+		{name: "成功", got: testutil.ToFloat64(m.handled.WithLabelValues("unary", info.FullMethod, codes.OK.String())), expect: 1},
+		{name: "失敗", got: testutil.ToFloat64(m.handled.WithLabelValues("unary", info.FullMethod, codes.InvalidArgument.String())), expect: 1},
+		{name: "処理中の数は戻る", got: testutil.ToFloat64(m.inFlight.WithLabelValues("unary", info.FullMethod)), expect: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expect {
+				t.Errorf("Expect: %v, Got: %v", tt.expect, tt.got)
+			}
+		})
+	}
+
+	t.Run("処理時間とメッセージの大きさ", func(t *testing.T) {
+		if n := testutil.CollectAndCount(m.duration); n != 1 {
+			t.Errorf("Expect: %v, Got: %v", 1, n)
+		}
+		// 受信した2件と送信した1件
+		if n := testutil.CollectAndCount(m.msgSize); n != 2 {
+			t.Errorf("Expect: %v, Got: %v", 2, n)
+		}
+	})
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	recv []*pb.CreateArticleRequest
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *fakeServerStream) RecvMsg(msg interface{}) error {
+	if len(s.recv) == 0 {
+		return status.Error(codes.Canceled, "closed")
+	}
+	*msg.(*pb.CreateArticleRequest) = pb.CreateArticleRequest{Title: s.recv[0].Title, Text: s.recv[0].Text}
+	s.recv = s.recv[1:]
+	return nil
+}
+
+func (s *fakeServerStream) SendMsg(interface{}) error {
+	return nil
+}
+
+func TestMetricsStreamInterceptor(t *testing.T) {
+	m := NewMetrics()
+	info := &grpc.StreamServerInfo{FullMethod: "/backend.BackendService/ImportArticles", IsClientStream: true}
+	ss := &fakeServerStream{recv: []*pb.CreateArticleRequest{{Title: "first"}, {Title: "second"}}}
+
+	err := MetricsStreamInterceptor(m)(nil, ss, info, func(srv interface{}, ss grpc.ServerStream) error {
+		for {
+			var req pb.CreateArticleRequest
+			if err := ss.RecvMsg(&req); err != nil {
+				break
+			}
+		}
+		return ss.SendMsg(&pb.CreateArticleResponse{ArticleId: 1})
+	})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	if got := testutil.ToFloat64(m.handled.WithLabelValues("client_stream", info.FullMethod, codes.OK.String())); got != 1 {
+		t.Errorf("Expect: %v, Got: %v", 1, got)
+	}
+
+	// 受信と送信のそれぞれで記録する
+	if n := testutil.CollectAndCount(m.msgSize); n != 2 {
+		t.Errorf("Expect: %v, Got: %v", 2, n)
+	}
+	if got := testutil.ToFloat64(m.inFlight.WithLabelValues("client_stream", info.FullMethod)); got != 0 {
+		t.Errorf("Expect: %v, Got: %v", 0, got)
+	}
+}
@@ -0,0 +1,129 @@
+package interceptor
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// 接続元ごとのリミッターを保持する上限。超えたらしばらく使われていないものから捨てる
+	maxRateLimitClients = 10000
+	rateLimitIdle       = 5 * time.Minute
+)
+
+// RateLimitFunc はリクエストのたびに呼ばれ、その時点の1秒あたりの上限とバーストを返す。rpsが0なら制限しない
+type RateLimitFunc func() (rps float64, burst int)
+
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter は接続元のIPアドレスごとにリクエスト数を制限する
+type RateLimiter struct {
+	limits RateLimitFunc
+
+	mu      sync.Mutex
+	clients map[string]*client
+}
+
+func NewRateLimiter(limits RateLimitFunc) *RateLimiter {
+	return &RateLimiter{
+		limits:  limits,
+		clients: make(map[string]*client),
+	}
+}
+
+// Allow はkeyからのリクエストを受け付けてよいかを判定する
+func (l *RateLimiter) Allow(key string) bool {
+	rps, burst := l.limits()
+	if rps <= 0 {
+		return true
+	}
+
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.clients[key]
+	if !ok {
+		if len(l.clients) >= maxRateLimitClients {
+			l.evict(now)
+		}
+		c = &client{limiter: rate.NewLimiter(rate.Limit(rps), burst)}
+		l.clients[key] = c
+	} else if c.limiter.Limit() != rate.Limit(rps) || c.limiter.Burst() != burst {
+		// 設定が再読み込みされた場合は既存のリミッターにも反映する
+		c.limiter.SetLimitAt(now, rate.Limit(rps))
+		c.limiter.SetBurstAt(now, burst)
+	}
+	c.lastSeen = now
+
+	return c.limiter.AllowN(now, 1)
+}
+
+func (l *RateLimiter) evict(now time.Time) {
+	for k, c := range l.clients {
+		if now.Sub(c.lastSeen) > rateLimitIdle {
+			delete(l.clients, k)
+		}
+	}
+
+	// すべて使用中の場合はメモリを優先して作り直す
+	if len(l.clients) >= maxRateLimitClients {
+		l.clients = make(map[string]*client)
+	}
+}
+
+func RateLimitInterceptor(l *RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		// ロードバランサーのヘルスチェックは制限しない
+		if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+			return handler(ctx, req)
+		}
+
+		if !l.Allow(peerKey(ctx)) {
+			return nil, status.Error(codes.ResourceExhausted, "too many requests")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func RateLimitStreamInterceptor(l *RateLimiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+			return handler(srv, ss)
+		}
+
+		if !l.Allow(peerKey(ss.Context())) {
+			return status.Error(codes.ResourceExhausted, "too many requests")
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// peerKey は接続元のIPアドレスを返す。ポートは接続ごとに変わるため含めない
+func peerKey(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,56 @@
+package interceptor
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/peer"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	rps, burst := 0.001, 2
+	l := NewRateLimiter(func() (float64, int) { return rps, burst })
+
+	t.Run("バーストを超えたら拒否する", func(t *testing.T) {
+		if !l.Allow("a") || !l.Allow("a") {
+			t.Fatal("requests within burst should be allowed")
+		}
+		if l.Allow("a") {
+			t.Error("request over burst should be rejected")
+		}
+		if !l.Allow("b") {
+			t.Error("other clients should be allowed")
+		}
+	})
+
+	t.Run("0にすると制限しない", func(t *testing.T) {
+		rps = 0
+		if !l.Allow("a") {
+			t.Error("request should be allowed when rate limit is disabled")
+		}
+	})
+
+	t.Run("変更した上限を既存の接続元にも反映する", func(t *testing.T) {
+		rps, burst = 10, 5
+		l.Allow("a")
+
+		limiter := l.clients["a"].limiter
+		if limiter.Limit() != 10 || limiter.Burst() != 5 {
+			t.Errorf("limiter should be updated: %v %v", limiter.Limit(), limiter.Burst())
+		}
+	})
+}
+
+func Test_peerKey(t *testing.T) {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 50000},
+	})
+
+	if got := peerKey(ctx); got != "192.0.2.1" {
+		t.Errorf("peerKey() = %v, want 192.0.2.1", got)
+	}
+	if got := peerKey(context.Background()); got != "" {
+		t.Errorf("peerKey() = %v, want empty", got)
+	}
+}
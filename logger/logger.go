@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// 出力するログの最低レベル。起動中に設定の再読み込みで変更される
+var level atomic.Int32
+
+func init() {
+	level.Store(int32(LevelInfo))
+}
+
+// ParseLevel はdebug, info, warn, errorのいずれかをLevelに変換する
+func ParseLevel(s string) (Level, bool) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LevelDebug, true
+	case "info":
+		return LevelInfo, true
+	case "warn":
+		return LevelWarn, true
+	case "error":
+		return LevelError, true
+	default:
+		return LevelInfo, false
+	}
+}
+
+func SetLevel(l Level) {
+	level.Store(int32(l))
+}
+
+func Enabled(l Level) bool {
+	return l >= Level(level.Load())
+}
+
+func Debugf(format string, args ...interface{}) {
+	logf(LevelDebug, "DEBUG", format, args...)
+}
+
+func Infof(format string, args ...interface{}) {
+	logf(LevelInfo, "INFO", format, args...)
+}
+
+func Warnf(format string, args ...interface{}) {
+	logf(LevelWarn, "WARN", format, args...)
+}
+
+func Errorf(format string, args ...interface{}) {
+	logf(LevelError, "ERROR", format, args...)
+}
+
+func logf(l Level, prefix, format string, args ...interface{}) {
+	if !Enabled(l) {
+		return
+	}
+	log.Printf(prefix+" "+format, args...)
+}
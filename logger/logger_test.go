@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+		SetLevel(LevelInfo)
+	}()
+
+	l, ok := ParseLevel("WARN")
+	if !ok {
+		t.Fatal("WARN should be parsed")
+	}
+	SetLevel(l)
+
+	Infof("info")
+	Warnf("warn %d", 1)
+
+	if got := buf.String(); got != "WARN warn 1\n" {
+		t.Errorf("only warn should be written: %q", got)
+	}
+
+	if _, ok := ParseLevel("verbose"); ok {
+		t.Error("unknown level should not be parsed")
+	}
+}
@@ -0,0 +1,77 @@
+package markdown
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+const DefaultCacheSize = 1024
+
+// Cache は記事ごとに最新の版のHTMLを保持するLRUキャッシュ。
+// 版は本文のハッシュで判定するため、更新された記事は次の取得時に描画し直される
+type Cache struct {
+	renderer Renderer
+	size     int
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[int64]*list.Element
+}
+
+type cacheEntry struct {
+	articleID int64
+	version   [sha256.Size]byte
+	html      string
+}
+
+func NewCache(renderer Renderer, size int) *Cache {
+	return &Cache{
+		renderer: renderer,
+		size:     size,
+		ll:       list.New(),
+		items:    make(map[int64]*list.Element),
+	}
+}
+
+func (c *Cache) Render(src string) (string, error) {
+	return c.renderer.Render(src)
+}
+
+func (c *Cache) RenderArticle(articleID int64, src string) (string, error) {
+	version := sha256.Sum256([]byte(src))
+
+	c.mu.Lock()
+	if el, ok := c.items[articleID]; ok {
+		entry := el.Value.(*cacheEntry)
+		if entry.version == version {
+			c.ll.MoveToFront(el)
+			c.mu.Unlock()
+			return entry.html, nil
+		}
+	}
+	c.mu.Unlock()
+
+	html, err := c.renderer.Render(src)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[articleID]; ok {
+		el.Value = &cacheEntry{articleID: articleID, version: version, html: html}
+		c.ll.MoveToFront(el)
+		return html, nil
+	}
+
+	c.items[articleID] = c.ll.PushFront(&cacheEntry{articleID: articleID, version: version, html: html})
+	if c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).articleID)
+	}
+
+	return html, nil
+}
@@ -0,0 +1,102 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdown_Render(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		expect string
+	}{
+		{
+			name:   "見出し",
+			src:    "# title",
+			expect: "<h1>title</h1>\n",
+		},
+		{
+			name:   "リンク",
+			src:    "[link](https://example.com)",
+			expect: `<p><a href="https://example.com" rel="nofollow">link</a></p>` + "\n",
+		},
+		{
+			name:   "scriptタグの除去",
+			src:    "<script>alert(1)</script>\n\ntext",
+			expect: "\n<p>text</p>\n",
+		},
+		{
+			name:   "javascriptスキームの除去",
+			src:    "[link](javascript:alert(1))",
+			expect: "<p>link</p>\n",
+		},
+		{
+			name:   "画像",
+			src:    "![alt](a.png)",
+			expect: `<p><img src="a.png" alt="alt"></p>`,
+		},
+		{
+			name:   "生のHTMLの除去",
+			src:    `<img src="a.png" onerror="alert(1)">`,
+			expect: "",
+		},
+	}
+
+	m := NewMarkdown()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Render(tt.src)
+			if err != nil {
+				t.Fatalf("err should be nil: %v", err)
+			}
+
+			if strings.TrimSpace(got) != strings.TrimSpace(tt.expect) {
+				t.Errorf("Expect: %q, Got: %q", tt.expect, got)
+			}
+		})
+	}
+}
+
+type countRenderer struct {
+	count int
+}
+
+func (r *countRenderer) Render(src string) (string, error) {
+	r.count++
+	return src, nil
+}
+
+func TestCache_RenderArticle(t *testing.T) {
+	r := &countRenderer{}
+	c := NewCache(r, 2)
+
+	render := func(id int64, src string) {
+		t.Helper()
+		got, err := c.RenderArticle(id, src)
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if got != src {
+			t.Errorf("Expect: %v, Got: %v", src, got)
+		}
+	}
+
+	render(1, "a")
+	render(1, "a")
+	if r.count != 1 {
+		t.Errorf("same version should be cached: %d", r.count)
+	}
+
+	render(1, "b")
+	if r.count != 2 {
+		t.Errorf("new version should be rendered: %d", r.count)
+	}
+
+	render(2, "c")
+	render(3, "d")
+	render(1, "b")
+	if r.count != 5 {
+		t.Errorf("least recently used entry should be evicted: %d", r.count)
+	}
+}
@@ -20,14 +20,26 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	BackendService_HelloWorld_FullMethodName    = "/backend.BackendService/HelloWorld"
-	BackendService_SignUp_FullMethodName        = "/backend.BackendService/SignUp"
-	BackendService_Login_FullMethodName         = "/backend.BackendService/Login"
-	BackendService_CreateArticle_FullMethodName = "/backend.BackendService/CreateArticle"
-	BackendService_GetArticles_FullMethodName   = "/backend.BackendService/GetArticles"
-	BackendService_GetArticle_FullMethodName    = "/backend.BackendService/GetArticle"
-	BackendService_UpdateArticle_FullMethodName = "/backend.BackendService/UpdateArticle"
-	BackendService_DeleteArticle_FullMethodName = "/backend.BackendService/DeleteArticle"
+	BackendService_HelloWorld_FullMethodName          = "/backend.BackendService/HelloWorld"
+	BackendService_SignUp_FullMethodName              = "/backend.BackendService/SignUp"
+	BackendService_Login_FullMethodName               = "/backend.BackendService/Login"
+	BackendService_Logout_FullMethodName              = "/backend.BackendService/Logout"
+	BackendService_CreateArticle_FullMethodName       = "/backend.BackendService/CreateArticle"
+	BackendService_GetArticles_FullMethodName         = "/backend.BackendService/GetArticles"
+	BackendService_GetArticle_FullMethodName          = "/backend.BackendService/GetArticle"
+	BackendService_UpdateArticle_FullMethodName       = "/backend.BackendService/UpdateArticle"
+	BackendService_DeleteArticle_FullMethodName       = "/backend.BackendService/DeleteArticle"
+	BackendService_EditArticle_FullMethodName         = "/backend.BackendService/EditArticle"
+	BackendService_BatchCreateArticles_FullMethodName = "/backend.BackendService/BatchCreateArticles"
+	BackendService_BatchGetArticles_FullMethodName    = "/backend.BackendService/BatchGetArticles"
+	BackendService_BatchDeleteArticles_FullMethodName = "/backend.BackendService/BatchDeleteArticles"
+	BackendService_ImportArticles_FullMethodName      = "/backend.BackendService/ImportArticles"
+	BackendService_ExportArticles_FullMethodName      = "/backend.BackendService/ExportArticles"
+	BackendService_RenderPreview_FullMethodName       = "/backend.BackendService/RenderPreview"
+	BackendService_UploadAttachment_FullMethodName    = "/backend.BackendService/UploadAttachment"
+	BackendService_DownloadAttachment_FullMethodName  = "/backend.BackendService/DownloadAttachment"
+	BackendService_GetUserSettings_FullMethodName     = "/backend.BackendService/GetUserSettings"
+	BackendService_UpdateUserSettings_FullMethodName  = "/backend.BackendService/UpdateUserSettings"
 )
 
 // BackendServiceClient is the client API for BackendService service.
@@ -37,11 +49,23 @@ type BackendServiceClient interface {
 	HelloWorld(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*HelloWorldResponse, error)
 	SignUp(ctx context.Context, in *SignUpRequest, opts ...grpc.CallOption) (*SignUpResponse, error)
 	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	Logout(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	CreateArticle(ctx context.Context, in *CreateArticleRequest, opts ...grpc.CallOption) (*CreateArticleResponse, error)
-	GetArticles(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*GetArticlesResponse, error)
+	GetArticles(ctx context.Context, in *GetArticlesRequest, opts ...grpc.CallOption) (*GetArticlesResponse, error)
 	GetArticle(ctx context.Context, in *GetArticleRequest, opts ...grpc.CallOption) (*GetArticleResponse, error)
 	UpdateArticle(ctx context.Context, in *UpdateArticleRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	DeleteArticle(ctx context.Context, in *DeleteArticleRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	EditArticle(ctx context.Context, opts ...grpc.CallOption) (BackendService_EditArticleClient, error)
+	BatchCreateArticles(ctx context.Context, in *BatchCreateArticlesRequest, opts ...grpc.CallOption) (*BatchCreateArticlesResponse, error)
+	BatchGetArticles(ctx context.Context, in *BatchGetArticlesRequest, opts ...grpc.CallOption) (*BatchGetArticlesResponse, error)
+	BatchDeleteArticles(ctx context.Context, in *BatchDeleteArticlesRequest, opts ...grpc.CallOption) (*BatchDeleteArticlesResponse, error)
+	ImportArticles(ctx context.Context, opts ...grpc.CallOption) (BackendService_ImportArticlesClient, error)
+	ExportArticles(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (BackendService_ExportArticlesClient, error)
+	RenderPreview(ctx context.Context, in *RenderPreviewRequest, opts ...grpc.CallOption) (*RenderPreviewResponse, error)
+	UploadAttachment(ctx context.Context, opts ...grpc.CallOption) (BackendService_UploadAttachmentClient, error)
+	DownloadAttachment(ctx context.Context, in *DownloadAttachmentRequest, opts ...grpc.CallOption) (BackendService_DownloadAttachmentClient, error)
+	GetUserSettings(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*UserSettings, error)
+	UpdateUserSettings(ctx context.Context, in *UpdateUserSettingsRequest, opts ...grpc.CallOption) (*UserSettings, error)
 }
 
 type backendServiceClient struct {
@@ -79,6 +103,15 @@ func (c *backendServiceClient) Login(ctx context.Context, in *LoginRequest, opts
 	return out, nil
 }
 
+func (c *backendServiceClient) Logout(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, BackendService_Logout_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *backendServiceClient) CreateArticle(ctx context.Context, in *CreateArticleRequest, opts ...grpc.CallOption) (*CreateArticleResponse, error) {
 	out := new(CreateArticleResponse)
 	err := c.cc.Invoke(ctx, BackendService_CreateArticle_FullMethodName, in, out, opts...)
@@ -88,7 +121,7 @@ func (c *backendServiceClient) CreateArticle(ctx context.Context, in *CreateArti
 	return out, nil
 }
 
-func (c *backendServiceClient) GetArticles(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*GetArticlesResponse, error) {
+func (c *backendServiceClient) GetArticles(ctx context.Context, in *GetArticlesRequest, opts ...grpc.CallOption) (*GetArticlesResponse, error) {
 	out := new(GetArticlesResponse)
 	err := c.cc.Invoke(ctx, BackendService_GetArticles_FullMethodName, in, out, opts...)
 	if err != nil {
@@ -124,6 +157,223 @@ func (c *backendServiceClient) DeleteArticle(ctx context.Context, in *DeleteArti
 	return out, nil
 }
 
+func (c *backendServiceClient) EditArticle(ctx context.Context, opts ...grpc.CallOption) (BackendService_EditArticleClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BackendService_ServiceDesc.Streams[0], BackendService_EditArticle_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &backendServiceEditArticleClient{stream}
+	return x, nil
+}
+
+type BackendService_EditArticleClient interface {
+	Send(*EditArticleRequest) error
+	Recv() (*EditArticleResponse, error)
+	grpc.ClientStream
+}
+
+type backendServiceEditArticleClient struct {
+	grpc.ClientStream
+}
+
+func (x *backendServiceEditArticleClient) Send(m *EditArticleRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *backendServiceEditArticleClient) Recv() (*EditArticleResponse, error) {
+	m := new(EditArticleResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *backendServiceClient) BatchCreateArticles(ctx context.Context, in *BatchCreateArticlesRequest, opts ...grpc.CallOption) (*BatchCreateArticlesResponse, error) {
+	out := new(BatchCreateArticlesResponse)
+	err := c.cc.Invoke(ctx, BackendService_BatchCreateArticles_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendServiceClient) BatchGetArticles(ctx context.Context, in *BatchGetArticlesRequest, opts ...grpc.CallOption) (*BatchGetArticlesResponse, error) {
+	out := new(BatchGetArticlesResponse)
+	err := c.cc.Invoke(ctx, BackendService_BatchGetArticles_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendServiceClient) BatchDeleteArticles(ctx context.Context, in *BatchDeleteArticlesRequest, opts ...grpc.CallOption) (*BatchDeleteArticlesResponse, error) {
+	out := new(BatchDeleteArticlesResponse)
+	err := c.cc.Invoke(ctx, BackendService_BatchDeleteArticles_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendServiceClient) ImportArticles(ctx context.Context, opts ...grpc.CallOption) (BackendService_ImportArticlesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BackendService_ServiceDesc.Streams[1], BackendService_ImportArticles_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &backendServiceImportArticlesClient{stream}
+	return x, nil
+}
+
+type BackendService_ImportArticlesClient interface {
+	Send(*ImportArticlesRequest) error
+	CloseAndRecv() (*ImportArticlesResponse, error)
+	grpc.ClientStream
+}
+
+type backendServiceImportArticlesClient struct {
+	grpc.ClientStream
+}
+
+func (x *backendServiceImportArticlesClient) Send(m *ImportArticlesRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *backendServiceImportArticlesClient) CloseAndRecv() (*ImportArticlesResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportArticlesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *backendServiceClient) ExportArticles(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (BackendService_ExportArticlesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BackendService_ServiceDesc.Streams[2], BackendService_ExportArticles_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &backendServiceExportArticlesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BackendService_ExportArticlesClient interface {
+	Recv() (*ExportArticlesResponse, error)
+	grpc.ClientStream
+}
+
+type backendServiceExportArticlesClient struct {
+	grpc.ClientStream
+}
+
+func (x *backendServiceExportArticlesClient) Recv() (*ExportArticlesResponse, error) {
+	m := new(ExportArticlesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *backendServiceClient) RenderPreview(ctx context.Context, in *RenderPreviewRequest, opts ...grpc.CallOption) (*RenderPreviewResponse, error) {
+	out := new(RenderPreviewResponse)
+	err := c.cc.Invoke(ctx, BackendService_RenderPreview_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendServiceClient) UploadAttachment(ctx context.Context, opts ...grpc.CallOption) (BackendService_UploadAttachmentClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BackendService_ServiceDesc.Streams[3], BackendService_UploadAttachment_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &backendServiceUploadAttachmentClient{stream}
+	return x, nil
+}
+
+type BackendService_UploadAttachmentClient interface {
+	Send(*UploadAttachmentRequest) error
+	CloseAndRecv() (*UploadAttachmentResponse, error)
+	grpc.ClientStream
+}
+
+type backendServiceUploadAttachmentClient struct {
+	grpc.ClientStream
+}
+
+func (x *backendServiceUploadAttachmentClient) Send(m *UploadAttachmentRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *backendServiceUploadAttachmentClient) CloseAndRecv() (*UploadAttachmentResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(UploadAttachmentResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *backendServiceClient) DownloadAttachment(ctx context.Context, in *DownloadAttachmentRequest, opts ...grpc.CallOption) (BackendService_DownloadAttachmentClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BackendService_ServiceDesc.Streams[4], BackendService_DownloadAttachment_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &backendServiceDownloadAttachmentClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BackendService_DownloadAttachmentClient interface {
+	Recv() (*DownloadAttachmentResponse, error)
+	grpc.ClientStream
+}
+
+type backendServiceDownloadAttachmentClient struct {
+	grpc.ClientStream
+}
+
+func (x *backendServiceDownloadAttachmentClient) Recv() (*DownloadAttachmentResponse, error) {
+	m := new(DownloadAttachmentResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *backendServiceClient) GetUserSettings(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*UserSettings, error) {
+	out := new(UserSettings)
+	err := c.cc.Invoke(ctx, BackendService_GetUserSettings_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendServiceClient) UpdateUserSettings(ctx context.Context, in *UpdateUserSettingsRequest, opts ...grpc.CallOption) (*UserSettings, error) {
+	out := new(UserSettings)
+	err := c.cc.Invoke(ctx, BackendService_UpdateUserSettings_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // BackendServiceServer is the server API for BackendService service.
 // All implementations must embed UnimplementedBackendServiceServer
 // for forward compatibility
@@ -131,11 +381,23 @@ type BackendServiceServer interface {
 	HelloWorld(context.Context, *emptypb.Empty) (*HelloWorldResponse, error)
 	SignUp(context.Context, *SignUpRequest) (*SignUpResponse, error)
 	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	Logout(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	CreateArticle(context.Context, *CreateArticleRequest) (*CreateArticleResponse, error)
-	GetArticles(context.Context, *emptypb.Empty) (*GetArticlesResponse, error)
+	GetArticles(context.Context, *GetArticlesRequest) (*GetArticlesResponse, error)
 	GetArticle(context.Context, *GetArticleRequest) (*GetArticleResponse, error)
 	UpdateArticle(context.Context, *UpdateArticleRequest) (*emptypb.Empty, error)
 	DeleteArticle(context.Context, *DeleteArticleRequest) (*emptypb.Empty, error)
+	EditArticle(BackendService_EditArticleServer) error
+	BatchCreateArticles(context.Context, *BatchCreateArticlesRequest) (*BatchCreateArticlesResponse, error)
+	BatchGetArticles(context.Context, *BatchGetArticlesRequest) (*BatchGetArticlesResponse, error)
+	BatchDeleteArticles(context.Context, *BatchDeleteArticlesRequest) (*BatchDeleteArticlesResponse, error)
+	ImportArticles(BackendService_ImportArticlesServer) error
+	ExportArticles(*emptypb.Empty, BackendService_ExportArticlesServer) error
+	RenderPreview(context.Context, *RenderPreviewRequest) (*RenderPreviewResponse, error)
+	UploadAttachment(BackendService_UploadAttachmentServer) error
+	DownloadAttachment(*DownloadAttachmentRequest, BackendService_DownloadAttachmentServer) error
+	GetUserSettings(context.Context, *emptypb.Empty) (*UserSettings, error)
+	UpdateUserSettings(context.Context, *UpdateUserSettingsRequest) (*UserSettings, error)
 	mustEmbedUnimplementedBackendServiceServer()
 }
 
@@ -152,10 +414,13 @@ func (UnimplementedBackendServiceServer) SignUp(context.Context, *SignUpRequest)
 func (UnimplementedBackendServiceServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
 }
+func (UnimplementedBackendServiceServer) Logout(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Logout not implemented")
+}
 func (UnimplementedBackendServiceServer) CreateArticle(context.Context, *CreateArticleRequest) (*CreateArticleResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateArticle not implemented")
 }
-func (UnimplementedBackendServiceServer) GetArticles(context.Context, *emptypb.Empty) (*GetArticlesResponse, error) {
+func (UnimplementedBackendServiceServer) GetArticles(context.Context, *GetArticlesRequest) (*GetArticlesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetArticles not implemented")
 }
 func (UnimplementedBackendServiceServer) GetArticle(context.Context, *GetArticleRequest) (*GetArticleResponse, error) {
@@ -167,6 +432,39 @@ func (UnimplementedBackendServiceServer) UpdateArticle(context.Context, *UpdateA
 func (UnimplementedBackendServiceServer) DeleteArticle(context.Context, *DeleteArticleRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteArticle not implemented")
 }
+func (UnimplementedBackendServiceServer) EditArticle(BackendService_EditArticleServer) error {
+	return status.Errorf(codes.Unimplemented, "method EditArticle not implemented")
+}
+func (UnimplementedBackendServiceServer) BatchCreateArticles(context.Context, *BatchCreateArticlesRequest) (*BatchCreateArticlesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreateArticles not implemented")
+}
+func (UnimplementedBackendServiceServer) BatchGetArticles(context.Context, *BatchGetArticlesRequest) (*BatchGetArticlesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetArticles not implemented")
+}
+func (UnimplementedBackendServiceServer) BatchDeleteArticles(context.Context, *BatchDeleteArticlesRequest) (*BatchDeleteArticlesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDeleteArticles not implemented")
+}
+func (UnimplementedBackendServiceServer) ImportArticles(BackendService_ImportArticlesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportArticles not implemented")
+}
+func (UnimplementedBackendServiceServer) ExportArticles(*emptypb.Empty, BackendService_ExportArticlesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportArticles not implemented")
+}
+func (UnimplementedBackendServiceServer) RenderPreview(context.Context, *RenderPreviewRequest) (*RenderPreviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenderPreview not implemented")
+}
+func (UnimplementedBackendServiceServer) UploadAttachment(BackendService_UploadAttachmentServer) error {
+	return status.Errorf(codes.Unimplemented, "method UploadAttachment not implemented")
+}
+func (UnimplementedBackendServiceServer) DownloadAttachment(*DownloadAttachmentRequest, BackendService_DownloadAttachmentServer) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadAttachment not implemented")
+}
+func (UnimplementedBackendServiceServer) GetUserSettings(context.Context, *emptypb.Empty) (*UserSettings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserSettings not implemented")
+}
+func (UnimplementedBackendServiceServer) UpdateUserSettings(context.Context, *UpdateUserSettingsRequest) (*UserSettings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUserSettings not implemented")
+}
 func (UnimplementedBackendServiceServer) mustEmbedUnimplementedBackendServiceServer() {}
 
 // UnsafeBackendServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -234,6 +532,24 @@ func _BackendService_Login_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BackendService_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServiceServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackendService_Logout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServiceServer).Logout(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _BackendService_CreateArticle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateArticleRequest)
 	if err := dec(in); err != nil {
@@ -253,7 +569,7 @@ func _BackendService_CreateArticle_Handler(srv interface{}, ctx context.Context,
 }
 
 func _BackendService_GetArticles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
+	in := new(GetArticlesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -265,7 +581,7 @@ func _BackendService_GetArticles_Handler(srv interface{}, ctx context.Context, d
 		FullMethod: BackendService_GetArticles_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BackendServiceServer).GetArticles(ctx, req.(*emptypb.Empty))
+		return srv.(BackendServiceServer).GetArticles(ctx, req.(*GetArticlesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -324,6 +640,234 @@ func _BackendService_DeleteArticle_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BackendService_EditArticle_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BackendServiceServer).EditArticle(&backendServiceEditArticleServer{stream})
+}
+
+type BackendService_EditArticleServer interface {
+	Send(*EditArticleResponse) error
+	Recv() (*EditArticleRequest, error)
+	grpc.ServerStream
+}
+
+type backendServiceEditArticleServer struct {
+	grpc.ServerStream
+}
+
+func (x *backendServiceEditArticleServer) Send(m *EditArticleResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *backendServiceEditArticleServer) Recv() (*EditArticleRequest, error) {
+	m := new(EditArticleRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _BackendService_BatchCreateArticles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateArticlesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServiceServer).BatchCreateArticles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackendService_BatchCreateArticles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServiceServer).BatchCreateArticles(ctx, req.(*BatchCreateArticlesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BackendService_BatchGetArticles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetArticlesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServiceServer).BatchGetArticles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackendService_BatchGetArticles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServiceServer).BatchGetArticles(ctx, req.(*BatchGetArticlesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BackendService_BatchDeleteArticles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDeleteArticlesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServiceServer).BatchDeleteArticles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackendService_BatchDeleteArticles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServiceServer).BatchDeleteArticles(ctx, req.(*BatchDeleteArticlesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BackendService_ImportArticles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BackendServiceServer).ImportArticles(&backendServiceImportArticlesServer{stream})
+}
+
+type BackendService_ImportArticlesServer interface {
+	SendAndClose(*ImportArticlesResponse) error
+	Recv() (*ImportArticlesRequest, error)
+	grpc.ServerStream
+}
+
+type backendServiceImportArticlesServer struct {
+	grpc.ServerStream
+}
+
+func (x *backendServiceImportArticlesServer) SendAndClose(m *ImportArticlesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *backendServiceImportArticlesServer) Recv() (*ImportArticlesRequest, error) {
+	m := new(ImportArticlesRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _BackendService_ExportArticles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BackendServiceServer).ExportArticles(m, &backendServiceExportArticlesServer{stream})
+}
+
+type BackendService_ExportArticlesServer interface {
+	Send(*ExportArticlesResponse) error
+	grpc.ServerStream
+}
+
+type backendServiceExportArticlesServer struct {
+	grpc.ServerStream
+}
+
+func (x *backendServiceExportArticlesServer) Send(m *ExportArticlesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BackendService_RenderPreview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenderPreviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServiceServer).RenderPreview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackendService_RenderPreview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServiceServer).RenderPreview(ctx, req.(*RenderPreviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BackendService_UploadAttachment_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BackendServiceServer).UploadAttachment(&backendServiceUploadAttachmentServer{stream})
+}
+
+type BackendService_UploadAttachmentServer interface {
+	SendAndClose(*UploadAttachmentResponse) error
+	Recv() (*UploadAttachmentRequest, error)
+	grpc.ServerStream
+}
+
+type backendServiceUploadAttachmentServer struct {
+	grpc.ServerStream
+}
+
+func (x *backendServiceUploadAttachmentServer) SendAndClose(m *UploadAttachmentResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *backendServiceUploadAttachmentServer) Recv() (*UploadAttachmentRequest, error) {
+	m := new(UploadAttachmentRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _BackendService_DownloadAttachment_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadAttachmentRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BackendServiceServer).DownloadAttachment(m, &backendServiceDownloadAttachmentServer{stream})
+}
+
+type BackendService_DownloadAttachmentServer interface {
+	Send(*DownloadAttachmentResponse) error
+	grpc.ServerStream
+}
+
+type backendServiceDownloadAttachmentServer struct {
+	grpc.ServerStream
+}
+
+func (x *backendServiceDownloadAttachmentServer) Send(m *DownloadAttachmentResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BackendService_GetUserSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServiceServer).GetUserSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackendService_GetUserSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServiceServer).GetUserSettings(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BackendService_UpdateUserSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateUserSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServiceServer).UpdateUserSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackendService_UpdateUserSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServiceServer).UpdateUserSettings(ctx, req.(*UpdateUserSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // BackendService_ServiceDesc is the grpc.ServiceDesc for BackendService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -343,6 +887,10 @@ var BackendService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Login",
 			Handler:    _BackendService_Login_Handler,
 		},
+		{
+			MethodName: "Logout",
+			Handler:    _BackendService_Logout_Handler,
+		},
 		{
 			MethodName: "CreateArticle",
 			Handler:    _BackendService_CreateArticle_Handler,
@@ -363,7 +911,58 @@ var BackendService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteArticle",
 			Handler:    _BackendService_DeleteArticle_Handler,
 		},
+		{
+			MethodName: "BatchCreateArticles",
+			Handler:    _BackendService_BatchCreateArticles_Handler,
+		},
+		{
+			MethodName: "BatchGetArticles",
+			Handler:    _BackendService_BatchGetArticles_Handler,
+		},
+		{
+			MethodName: "BatchDeleteArticles",
+			Handler:    _BackendService_BatchDeleteArticles_Handler,
+		},
+		{
+			MethodName: "RenderPreview",
+			Handler:    _BackendService_RenderPreview_Handler,
+		},
+		{
+			MethodName: "GetUserSettings",
+			Handler:    _BackendService_GetUserSettings_Handler,
+		},
+		{
+			MethodName: "UpdateUserSettings",
+			Handler:    _BackendService_UpdateUserSettings_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "EditArticle",
+			Handler:       _BackendService_EditArticle_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ImportArticles",
+			Handler:       _BackendService_ImportArticles_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ExportArticles",
+			Handler:       _BackendService_ExportArticles_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "UploadAttachment",
+			Handler:       _BackendService_UploadAttachment_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DownloadAttachment",
+			Handler:       _BackendService_DownloadAttachment_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "backend.proto",
 }
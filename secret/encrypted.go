@@ -0,0 +1,129 @@
+package secret
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const KeySize = 32
+
+// EncryptedFileProvider はAES-256-GCMで暗号化したJSONのファイルから読む。
+// 鍵はファイルとは別に配布し、リポジトリには暗号化したファイルだけを置けるようにする
+type EncryptedFileProvider struct {
+	path string
+	key  []byte
+}
+
+func NewEncryptedFileProvider(path string, key []byte) *EncryptedFileProvider {
+	return &EncryptedFileProvider{path: path, key: key}
+}
+
+func (p *EncryptedFileProvider) Get(ctx context.Context, name string) (string, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", xerrors.Errorf("failed to read secret file: %v", err)
+	}
+
+	values, err := Decrypt(p.key, data)
+	if err != nil {
+		return "", err
+	}
+
+	v, ok := values[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+// GenerateKey は新しい鍵をbase64でエンコードして返す
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", xerrors.Errorf("failed to generate key: %v", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// LoadKey はbase64でエンコードされた鍵のファイルを読む
+func LoadKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read secret key: %v", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != KeySize {
+		return nil, xerrors.Errorf("secret key must be %d bytes encoded in base64", KeySize)
+	}
+
+	return key, nil
+}
+
+// Encrypt はシークレットの一覧を暗号化する。先頭にnonceを付けて返す
+func Encrypt(key []byte, values map[string]string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := json.Marshal(values)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to encode secrets: %v", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, xerrors.Errorf("failed to generate nonce: %v", err)
+	}
+
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+func Decrypt(key, data []byte) (map[string]string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, xerrors.New("secret file is corrupted")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, xerrors.New("failed to decrypt secret file: wrong key or corrupted file")
+	}
+
+	values := make(map[string]string)
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return nil, xerrors.Errorf("failed to decode secrets: %v", err)
+	}
+
+	return values, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid secret key: %v", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid secret key: %v", err)
+	}
+
+	return gcm, nil
+}
@@ -0,0 +1,94 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sample-grpc-server/config"
+
+	"golang.org/x/xerrors"
+)
+
+// データベースの認証情報のシークレット名
+const (
+	DBUser     = "db_user"
+	DBPassword = "db_password"
+)
+
+var ErrNotFound = errors.New("secret: not found")
+
+// Provider はシークレットを取得する。呼び出すたびに最新の値を読むため、ローテーションされた値も取得できる
+//
+//go:generate mockgen -source=$GOFILE -destination=mock/mock_$GOFILE
+type Provider interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// NewProvider は設定のsecret.providerに応じたProviderを返す
+func NewProvider(c *config.Config) (Provider, error) {
+	switch c.GetSecretProvider() {
+	case "file":
+		return NewFileProvider(c.GetSecretDir()), nil
+	case "encrypted":
+		key, err := LoadKey(c.GetSecretKeyFile())
+		if err != nil {
+			return nil, err
+		}
+		return NewEncryptedFileProvider(c.GetSecretFile(), key), nil
+	default:
+		return NewEnvProvider(), nil
+	}
+}
+
+// EnvProvider は名前を大文字にした環境変数から読む。NAME_FILEが指定されていればそのファイルから読む
+type EnvProvider struct{}
+
+func NewEnvProvider() *EnvProvider {
+	return &EnvProvider{}
+}
+
+func (p *EnvProvider) Get(ctx context.Context, name string) (string, error) {
+	env := strings.ToUpper(name)
+
+	if path := os.Getenv(env + "_FILE"); path != "" {
+		return readFile(path)
+	}
+
+	v, ok := os.LookupEnv(env)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+// FileProvider はディレクトリ内のシークレット名のファイルから読む。Kubernetesのシークレットのマウント先を想定している
+type FileProvider struct {
+	dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+func (p *FileProvider) Get(ctx context.Context, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", xerrors.Errorf("secret: invalid name %q", name)
+	}
+
+	return readFile(filepath.Join(p.dir, name))
+}
+
+func readFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", xerrors.Errorf("failed to read secret: %v", err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,111 @@
+package secret
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvProvider_Get(t *testing.T) {
+	ctx := context.Background()
+	p := NewEnvProvider()
+
+	t.Run("環境変数から読む", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD", "password")
+
+		got, err := p.Get(ctx, DBPassword)
+		if err != nil || got != "password" {
+			t.Errorf("Get() = %v, %v", got, err)
+		}
+	})
+
+	t.Run("_FILEが優先される", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "db_password")
+		if err := os.WriteFile(path, []byte("rotated\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("DB_PASSWORD", "password")
+		t.Setenv("DB_PASSWORD_FILE", path)
+
+		got, err := p.Get(ctx, DBPassword)
+		if err != nil || got != "rotated" {
+			t.Errorf("Get() = %v, %v", got, err)
+		}
+	})
+
+	t.Run("未設定", func(t *testing.T) {
+		if _, err := p.Get(ctx, "not_exist"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("err should be ErrNotFound: %v", err)
+		}
+	})
+}
+
+func TestFileProvider_Get(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, DBUser), []byte("app"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := NewFileProvider(dir)
+
+	if got, err := p.Get(ctx, DBUser); err != nil || got != "app" {
+		t.Errorf("Get() = %v, %v", got, err)
+	}
+	if _, err := p.Get(ctx, DBPassword); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err should be ErrNotFound: %v", err)
+	}
+	if _, err := p.Get(ctx, "../"+DBUser); err == nil {
+		t.Error("path traversal should be rejected")
+	}
+}
+
+func TestEncryptedFileProvider_Get(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	encoded, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyPath, []byte(encoded+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := LoadKey(keyPath)
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	data, err := Encrypt(key, map[string]string{DBPassword: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "secrets.enc")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("復号して読む", func(t *testing.T) {
+		got, err := NewEncryptedFileProvider(path, key).Get(ctx, DBPassword)
+		if err != nil || got != "s3cret" {
+			t.Errorf("Get() = %v, %v", got, err)
+		}
+	})
+
+	t.Run("含まれていない名前", func(t *testing.T) {
+		_, err := NewEncryptedFileProvider(path, key).Get(ctx, DBUser)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("err should be ErrNotFound: %v", err)
+		}
+	})
+
+	t.Run("鍵が異なる", func(t *testing.T) {
+		other, _ := base64.StdEncoding.DecodeString("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+		if _, err := NewEncryptedFileProvider(path, other).Get(ctx, DBPassword); err == nil {
+			t.Error("err should not be nil")
+		}
+	})
+}
@@ -0,0 +1,331 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"mime"
+	"path"
+	"strings"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/database/model"
+	"sample-grpc-server/logger"
+	"sample-grpc-server/pb"
+	"sample-grpc-server/thumbnail"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const attachmentChunkSize = 32 * 1024
+
+var (
+	errAttachmentTooLarge = errors.New("attachment is too large")
+	errUnexpectedMetadata = errors.New("metadata must be sent only once")
+)
+
+// uploadReader はストリームで受信したチャンクを読み出しながらサイズとハッシュを計算する
+type uploadReader struct {
+	stream pb.BackendService_UploadAttachmentServer
+	buf    []byte
+	size   int64
+	max    int64
+	hash   hash.Hash
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		req, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+
+		if req.GetMetadata() != nil {
+			return 0, errUnexpectedMetadata
+		}
+
+		chunk := req.GetChunk()
+		r.size += int64(len(chunk))
+		if r.size > r.max {
+			return 0, errAttachmentTooLarge
+		}
+
+		r.hash.Write(chunk)
+		r.buf = chunk
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
+
+func (s *Server) UploadAttachment(stream pb.BackendService_UploadAttachmentServer) error {
+	if s.blobs == nil {
+		return status.Error(codes.Unimplemented, "attachments are not configured")
+	}
+
+	ctx := stream.Context()
+	userID := extractUserID(ctx)
+
+	req, err := stream.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return status.Error(codes.InvalidArgument, "metadata is required")
+		}
+		return err
+	}
+
+	meta := req.GetMetadata()
+	if meta == nil {
+		return status.Error(codes.InvalidArgument, "the first message must be metadata")
+	}
+
+	filename := path.Base(strings.ReplaceAll(meta.GetFilename(), `\`, "/"))
+	if filename == "" || filename == "." || filename == "/" {
+		return status.Error(codes.InvalidArgument, "filename is required")
+	}
+
+	contentType, ok := s.allowedContentType(meta.GetContentType())
+	if !ok {
+		return status.Error(codes.InvalidArgument, "content type is not allowed")
+	}
+
+	expectedSum, err := hex.DecodeString(meta.GetSha256())
+	if err != nil || len(expectedSum) != sha256.Size {
+		return status.Error(codes.InvalidArgument, "sha256 must be a hex encoded digest")
+	}
+
+	if _, err := s.db.GetArticle(ctx, database.GetArticleParams{
+		ArticleID: meta.GetArticleId(),
+		UserID:    userID,
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return status.Error(codes.NotFound, "article not found")
+		}
+		return status.Error(codes.Internal, "server error")
+	}
+
+	key := uuid.NewString()
+	r := &uploadReader{
+		stream: stream,
+		max:    s.attachmentLimits.MaxSize,
+		hash:   sha256.New(),
+	}
+
+	size, err := s.blobs.Put(ctx, key, r)
+	if err != nil {
+		switch {
+		case errors.Is(err, errAttachmentTooLarge):
+			return status.Errorf(codes.InvalidArgument, "attachment must be %d bytes or less", s.attachmentLimits.MaxSize)
+		case errors.Is(err, errUnexpectedMetadata):
+			return status.Error(codes.InvalidArgument, errUnexpectedMetadata.Error())
+		case ctx.Err() != nil:
+			return status.FromContextError(ctx.Err()).Err()
+		default:
+			return status.Error(codes.Internal, "server error")
+		}
+	}
+
+	if sum := r.hash.Sum(nil); !strings.EqualFold(hex.EncodeToString(sum), meta.GetSha256()) {
+		s.deleteBlob(key)
+		return status.Error(codes.DataLoss, "sha256 does not match")
+	}
+
+	params := database.CreateAttachmentParams{
+		ArticleID:   meta.GetArticleId(),
+		UserID:      userID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      strings.ToLower(meta.GetSha256()),
+		StorageKey:  key,
+	}
+
+	dbResp, err := s.db.CreateAttachment(ctx, params)
+	if err != nil {
+		s.deleteBlob(key)
+		return status.Error(codes.Internal, "server error")
+	}
+
+	if s.thumbnails != nil && thumbnail.Supported(contentType) {
+		// キューが満杯の場合もアップロード自体は成功として扱う
+		if !s.thumbnails.Enqueue(thumbnail.Job{
+			AttachmentID: dbResp.AttachmentID,
+			StorageKey:   key,
+			ContentType:  contentType,
+		}) {
+			logger.Warnf("thumbnail queue is full, skipped attachment %d", dbResp.AttachmentID)
+		}
+	}
+
+	return stream.SendAndClose(&pb.UploadAttachmentResponse{
+		Attachment: attachmentToPB(model.Attachment{
+			ID:          dbResp.AttachmentID,
+			ArticleID:   params.ArticleID,
+			Filename:    params.Filename,
+			ContentType: params.ContentType,
+			Size:        params.Size,
+			SHA256:      params.SHA256,
+			CreatedAt:   dbResp.CreatedAt,
+		}),
+	})
+}
+
+func (s *Server) DownloadAttachment(req *pb.DownloadAttachmentRequest, stream pb.BackendService_DownloadAttachmentServer) error {
+	if s.blobs == nil {
+		return status.Error(codes.Unimplemented, "attachments are not configured")
+	}
+
+	ctx := stream.Context()
+	userID := extractUserID(ctx)
+
+	dbResp, err := s.db.GetAttachment(ctx, database.GetAttachmentParams{
+		AttachmentID: req.GetAttachmentId(),
+		UserID:       userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return status.Error(codes.NotFound, "attachment not found")
+		}
+		return status.Error(codes.Internal, "server error")
+	}
+
+	attachment := attachmentToPB(dbResp.Attachment)
+	key := dbResp.Attachment.StorageKey
+
+	// サムネイルが指定された場合はそのサイズとContent-Typeで返す
+	if req.ThumbnailSize != nil {
+		th := findThumbnail(dbResp.Attachment.Thumbnails, int(req.GetThumbnailSize()))
+		if th == nil {
+			return status.Error(codes.NotFound, "thumbnail not found")
+		}
+		key = th.StorageKey
+		attachment.ContentType = th.ContentType
+	}
+
+	rc, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		logger.Errorf("failed to open blob of attachment %d: %v", dbResp.Attachment.ID, err)
+		return status.Error(codes.Internal, "server error")
+	}
+	defer rc.Close()
+
+	if err := stream.Send(&pb.DownloadAttachmentResponse{
+		Payload: &pb.DownloadAttachmentResponse_Attachment{
+			Attachment: attachment,
+		},
+	}); err != nil {
+		return err
+	}
+
+	buf := make([]byte, attachmentChunkSize)
+	for {
+		n, err := rc.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&pb.DownloadAttachmentResponse{
+				Payload: &pb.DownloadAttachmentResponse_Chunk{Chunk: buf[:n]},
+			}); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Internal, "server error")
+		}
+	}
+}
+
+// allowedContentType はパラメータを除いたContent-Typeが許可リストに含まれるかを判定する
+func (s *Server) allowedContentType(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+
+	for _, t := range s.attachmentLimits.AllowedTypes {
+		if strings.EqualFold(t, mediaType) {
+			return mediaType, true
+		}
+	}
+
+	return "", false
+}
+
+// setAttachments は添付ファイルが有効な場合に記事へ添付ファイルとサムネイルの情報を設定する
+func (s *Server) setAttachments(ctx context.Context, userID int64, articles ...*pb.Article) error {
+	if s.blobs == nil || len(articles) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(articles))
+	for _, a := range articles {
+		ids = append(ids, a.ArticleId)
+	}
+
+	dbResp, err := s.db.GetArticleAttachments(ctx, database.GetArticleAttachmentsParams{
+		ArticleIDs: ids,
+		UserID:     userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	byArticle := make(map[int64][]*pb.Attachment, len(articles))
+	for _, a := range dbResp.Attachments {
+		byArticle[a.ArticleID] = append(byArticle[a.ArticleID], attachmentToPB(a))
+	}
+
+	for _, a := range articles {
+		a.Attachments = byArticle[a.ArticleId]
+	}
+
+	return nil
+}
+
+func findThumbnail(thumbnails []*model.Thumbnail, size int) *model.Thumbnail {
+	for _, th := range thumbnails {
+		if th.Size == size {
+			return th
+		}
+	}
+	return nil
+}
+
+func (s *Server) deleteBlob(key string) {
+	// アップロードのコンテキストはキャンセル済みの場合があるため独立したコンテキストで削除する
+	if err := s.blobs.Delete(context.Background(), key); err != nil {
+		logger.Warnf("failed to delete blob %s: %v", key, err)
+	}
+}
+
+func attachmentToPB(a model.Attachment) *pb.Attachment {
+	attachment := &pb.Attachment{
+		AttachmentId: a.ID,
+		ArticleId:    a.ArticleID,
+		Filename:     a.Filename,
+		ContentType:  a.ContentType,
+		Size:         a.Size,
+		Sha256:       a.SHA256,
+		CreatedAt:    timestampPtr(a.CreatedAt),
+	}
+
+	for _, th := range a.Thumbnails {
+		attachment.Thumbnails = append(attachment.Thumbnails, &pb.Thumbnail{
+			Size:        uint32(th.Size),
+			Width:       uint32(th.Width),
+			Height:      uint32(th.Height),
+			ContentType: th.ContentType,
+		})
+	}
+
+	return attachment
+}
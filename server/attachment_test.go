@@ -0,0 +1,316 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"sample-grpc-server/database"
+	mock_database "sample-grpc-server/database/mock"
+	"sample-grpc-server/database/model"
+	"sample-grpc-server/pb"
+	"sample-grpc-server/storage"
+	mock_storage "sample-grpc-server/storage/mock"
+
+	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeUploadStream struct {
+	grpc.ServerStream
+
+	ctx  context.Context
+	reqs []*pb.UploadAttachmentRequest
+	resp *pb.UploadAttachmentResponse
+}
+
+func (f *fakeUploadStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeUploadStream) Recv() (*pb.UploadAttachmentRequest, error) {
+	if len(f.reqs) == 0 {
+		return nil, io.EOF
+	}
+	req := f.reqs[0]
+	f.reqs = f.reqs[1:]
+	return req, nil
+}
+
+func (f *fakeUploadStream) SendAndClose(resp *pb.UploadAttachmentResponse) error {
+	f.resp = resp
+	return nil
+}
+
+type fakeDownloadStream struct {
+	grpc.ServerStream
+
+	ctx   context.Context
+	resps []*pb.DownloadAttachmentResponse
+}
+
+func (f *fakeDownloadStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeDownloadStream) Send(resp *pb.DownloadAttachmentResponse) error {
+	f.resps = append(f.resps, resp)
+	return nil
+}
+
+func TestServer_UploadAttachment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	data := []byte("hello attachment")
+	sum := sha256.Sum256(data)
+
+	limits := AttachmentLimits{MaxSize: 1024, AllowedTypes: []string{"text/plain"}}
+
+	upload := func(db database.Querier, meta *pb.AttachmentMetadata, chunks ...[]byte) (*fakeUploadStream, error) {
+		t.Helper()
+
+		blobs, err := storage.NewLocalBlobStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		stream := &fakeUploadStream{
+			ctx: context.WithValue(context.Background(), KeyUserID, int64(1)),
+			reqs: []*pb.UploadAttachmentRequest{
+				{Payload: &pb.UploadAttachmentRequest_Metadata{Metadata: meta}},
+			},
+		}
+		for _, c := range chunks {
+			stream.reqs = append(stream.reqs, &pb.UploadAttachmentRequest{
+				Payload: &pb.UploadAttachmentRequest_Chunk{Chunk: c},
+			})
+		}
+
+		return stream, NewServer(db, nil, nil, WithBlobStore(blobs, limits)).UploadAttachment(stream)
+	}
+
+	metadata := func(contentType, digest string) *pb.AttachmentMetadata {
+		return &pb.AttachmentMetadata{
+			ArticleId:   1,
+			Filename:    "../../notes.txt",
+			ContentType: contentType,
+			Sha256:      digest,
+		}
+	}
+
+	t.Run("リクエスト成功", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().GetArticle(gomock.Any(), gomock.Any()).Return(&database.GetArticleResult{}, nil)
+		db.EXPECT().CreateAttachment(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, p database.CreateAttachmentParams) (*database.CreateAttachmentResult, error) {
+				if p.Filename != "notes.txt" {
+					t.Errorf("Expect: %v, Got: %v", "notes.txt", p.Filename)
+				}
+				if p.Size != int64(len(data)) {
+					t.Errorf("Expect: %v, Got: %v", len(data), p.Size)
+				}
+				return &database.CreateAttachmentResult{AttachmentID: 10, CreatedAt: time.Now()}, nil
+			})
+
+		stream, err := upload(db, metadata("text/plain; charset=utf-8", hex.EncodeToString(sum[:])), data[:5], data[5:])
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if stream.resp.Attachment.AttachmentId != 10 || stream.resp.Attachment.ContentType != "text/plain" {
+			t.Errorf("unexpected attachment: %v", stream.resp.Attachment)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		meta   *pb.AttachmentMetadata
+		chunks [][]byte
+		code   codes.Code
+	}{
+		{
+			name:   "許可されていないContent-Type",
+			meta:   metadata("application/x-sh", hex.EncodeToString(sum[:])),
+			chunks: [][]byte{data},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "不正なハッシュ",
+			meta:   metadata("text/plain", "xyz"),
+			chunks: [][]byte{data},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "ハッシュの不一致",
+			meta:   metadata("text/plain", hex.EncodeToString(make([]byte, sha256.Size))),
+			chunks: [][]byte{data},
+			code:   codes.DataLoss,
+		},
+		{
+			name:   "サイズ超過",
+			meta:   metadata("text/plain", hex.EncodeToString(sum[:])),
+			chunks: [][]byte{bytes.Repeat([]byte("a"), 1025)},
+			code:   codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := mock_database.NewMockQuerier(ctrl)
+			db.EXPECT().GetArticle(gomock.Any(), gomock.Any()).Return(&database.GetArticleResult{}, nil).AnyTimes()
+
+			_, err := upload(db, tt.meta, tt.chunks...)
+			if status.Code(err) != tt.code {
+				t.Errorf("Expect: %v, Got: %v", tt.code, err)
+			}
+		})
+	}
+
+	t.Run("記事が存在しない", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().GetArticle(gomock.Any(), gomock.Any()).Return(nil, errors.New("some error"))
+
+		_, err := upload(db, metadata("text/plain", hex.EncodeToString(sum[:])), data)
+		if status.Code(err) != codes.Internal {
+			t.Errorf("Expect: %v, Got: %v", codes.Internal, err)
+		}
+	})
+}
+
+func TestServer_DownloadAttachment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	blobs, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	data := bytes.Repeat([]byte("a"), attachmentChunkSize+1)
+	if _, err := blobs.Put(ctx, "key", bytes.NewReader(data)); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	db := mock_database.NewMockQuerier(ctrl)
+	db.EXPECT().GetAttachment(gomock.Any(), gomock.Any()).Return(&database.GetAttachmentResult{
+		Attachment: model.Attachment{ID: 1, Filename: "a.txt", Size: int64(len(data)), StorageKey: "key"},
+	}, nil)
+
+	stream := &fakeDownloadStream{ctx: context.WithValue(ctx, KeyUserID, int64(1))}
+	s := NewServer(db, nil, nil, WithBlobStore(blobs, AttachmentLimits{}))
+	if err := s.DownloadAttachment(&pb.DownloadAttachmentRequest{AttachmentId: 1}, stream); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	if len(stream.resps) != 3 {
+		t.Fatalf("Expect: %v, Got: %v", 3, len(stream.resps))
+	}
+
+	if stream.resps[0].GetAttachment().GetFilename() != "a.txt" {
+		t.Errorf("first message should be metadata: %v", stream.resps[0])
+	}
+
+	var got []byte
+	for _, resp := range stream.resps[1:] {
+		got = append(got, resp.GetChunk()...)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("downloaded data does not match")
+	}
+}
+
+func TestServer_DownloadAttachment_Thumbnail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	blobs, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if _, err := blobs.Put(ctx, "thumbnails/key/128", bytes.NewReader([]byte("thumb"))); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	attachment := model.Attachment{
+		ID:          1,
+		ContentType: "image/gif",
+		StorageKey:  "key",
+		Thumbnails: []*model.Thumbnail{
+			{Size: 128, Width: 128, Height: 64, ContentType: "image/png", StorageKey: "thumbnails/key/128"},
+		},
+	}
+
+	size := func(n uint32) *uint32 { return &n }
+
+	t.Run("リクエスト成功", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().GetAttachment(gomock.Any(), gomock.Any()).Return(&database.GetAttachmentResult{Attachment: attachment}, nil)
+
+		stream := &fakeDownloadStream{ctx: context.WithValue(ctx, KeyUserID, int64(1))}
+		s := NewServer(db, nil, nil, WithBlobStore(blobs, AttachmentLimits{}))
+		if err := s.DownloadAttachment(&pb.DownloadAttachmentRequest{AttachmentId: 1, ThumbnailSize: size(128)}, stream); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if stream.resps[0].GetAttachment().GetContentType() != "image/png" {
+			t.Errorf("Expect: %v, Got: %v", "image/png", stream.resps[0].GetAttachment().GetContentType())
+		}
+		if string(stream.resps[1].GetChunk()) != "thumb" {
+			t.Errorf("Expect: %v, Got: %v", "thumb", string(stream.resps[1].GetChunk()))
+		}
+	})
+
+	t.Run("存在しないサイズ", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().GetAttachment(gomock.Any(), gomock.Any()).Return(&database.GetAttachmentResult{Attachment: attachment}, nil)
+
+		stream := &fakeDownloadStream{ctx: context.WithValue(ctx, KeyUserID, int64(1))}
+		s := NewServer(db, nil, nil, WithBlobStore(blobs, AttachmentLimits{}))
+		err := s.DownloadAttachment(&pb.DownloadAttachmentRequest{AttachmentId: 1, ThumbnailSize: size(64)}, stream)
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("Expect: %v, Got: %v", codes.NotFound, err)
+		}
+	})
+}
+
+func TestServer_GetArticle_Attachments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.WithValue(context.Background(), KeyUserID, int64(1))
+
+	db := mock_database.NewMockQuerier(ctrl)
+	db.EXPECT().GetArticle(gomock.Any(), gomock.Any()).Return(&database.GetArticleResult{
+		Article: model.Article{ID: 1, Title: "title"},
+	}, nil)
+	db.EXPECT().GetArticleAttachments(gomock.Any(), database.GetArticleAttachmentsParams{
+		ArticleIDs: []int64{1},
+		UserID:     1,
+	}).Return(&database.GetArticleAttachmentsResult{
+		Attachments: []model.Attachment{
+			{ID: 10, ArticleID: 1, Filename: "a.png", Thumbnails: []*model.Thumbnail{{Size: 128, Width: 128, Height: 128}}},
+		},
+	}, nil)
+
+	s := NewServer(db, nil, nil, WithBlobStore(mock_storage.NewMockBlobStore(ctrl), AttachmentLimits{}))
+
+	resp, err := s.GetArticle(ctx, &pb.GetArticleRequest{ArticleId: 1})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	attachments := resp.Article.GetAttachments()
+	if len(attachments) != 1 || attachments[0].GetAttachmentId() != 10 || len(attachments[0].GetThumbnails()) != 1 {
+		t.Errorf("unexpected attachments: %v", attachments)
+	}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/pb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxBatchSize = 100
+
+func (s *Server) BatchCreateArticles(ctx context.Context, req *pb.BatchCreateArticlesRequest) (*pb.BatchCreateArticlesResponse, error) {
+	userID := extractUserID(ctx)
+
+	if err := validateBatchSize(len(req.GetArticles())); err != nil {
+		return nil, err
+	}
+
+	results := make([]*pb.BatchCreateArticleResult, len(req.GetArticles()))
+	params := database.BatchCreateArticlesParams{UserID: userID}
+	indexes := make([]int, 0, len(req.GetArticles()))
+
+	for i, article := range req.GetArticles() {
+		results[i] = &pb.BatchCreateArticleResult{}
+
+		if article.GetTitle() == "" {
+			results[i].Error = batchError(codes.InvalidArgument, "title is required")
+			continue
+		}
+
+		params.Articles = append(params.Articles, database.BatchCreateArticle{
+			Title:       article.GetTitle(),
+			Description: convNullString(article.Description),
+			Text:        article.GetText(),
+		})
+		indexes = append(indexes, i)
+	}
+
+	if req.GetAllOrNothing() && len(indexes) != len(results) {
+		for _, i := range indexes {
+			results[i].Error = batchError(codes.Aborted, "batch is aborted")
+		}
+		return &pb.BatchCreateArticlesResponse{Results: results}, nil
+	}
+
+	dbResp, err := s.db.BatchCreateArticles(ctx, params)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	for n, i := range indexes {
+		results[i].ArticleId = dbResp.ArticleIDs[n]
+	}
+	s.metrics.articlesCreated.WithLabelValues("batch").Add(float64(len(dbResp.ArticleIDs)))
+
+	return &pb.BatchCreateArticlesResponse{Results: results}, nil
+}
+
+func (s *Server) BatchGetArticles(ctx context.Context, req *pb.BatchGetArticlesRequest) (*pb.BatchGetArticlesResponse, error) {
+	userID := extractUserID(ctx)
+
+	if err := validateBatchSize(len(req.GetArticleIds())); err != nil {
+		return nil, err
+	}
+
+	dbResp, err := s.db.BatchGetArticles(ctx, database.BatchGetArticlesParams{
+		ArticleIDs: req.GetArticleIds(),
+		UserID:     userID,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	articles := make(map[int64]*pb.Article, len(dbResp.Articles))
+	found := make([]*pb.Article, 0, len(dbResp.Articles))
+	for _, article := range dbResp.Articles {
+		articles[article.ID] = s.articleToPB(article)
+		found = append(found, articles[article.ID])
+	}
+
+	if err := s.setAttachments(ctx, userID, found...); err != nil {
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	resp := &pb.BatchGetArticlesResponse{}
+	for _, id := range req.GetArticleIds() {
+		result := &pb.BatchGetArticleResult{ArticleId: id}
+		if article, ok := articles[id]; ok {
+			result.Article = article
+		} else {
+			result.Error = batchError(codes.NotFound, "article not found")
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, nil
+}
+
+func (s *Server) BatchDeleteArticles(ctx context.Context, req *pb.BatchDeleteArticlesRequest) (*pb.BatchDeleteArticlesResponse, error) {
+	userID := extractUserID(ctx)
+
+	if err := validateBatchSize(len(req.GetArticleIds())); err != nil {
+		return nil, err
+	}
+
+	dbResp, err := s.db.BatchDeleteArticles(ctx, database.BatchDeleteArticlesParams{
+		ArticleIDs:   req.GetArticleIds(),
+		UserID:       userID,
+		AllOrNothing: req.GetAllOrNothing(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	missing := make(map[int64]bool, len(dbResp.MissingIDs))
+	for _, id := range dbResp.MissingIDs {
+		missing[id] = true
+	}
+
+	resp := &pb.BatchDeleteArticlesResponse{}
+	for _, id := range req.GetArticleIds() {
+		result := &pb.BatchDeleteArticleResult{ArticleId: id}
+		switch {
+		case missing[id]:
+			result.Error = batchError(codes.NotFound, "article not found")
+		case req.GetAllOrNothing() && len(dbResp.DeletedIDs) == 0:
+			result.Error = batchError(codes.Aborted, "batch is aborted")
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, nil
+}
+
+func validateBatchSize(n int) error {
+	if n == 0 {
+		return status.Error(codes.InvalidArgument, "batch is empty")
+	}
+	if n > maxBatchSize {
+		return status.Errorf(codes.InvalidArgument, "batch size must be %d or less", maxBatchSize)
+	}
+	return nil
+}
+
+func batchError(code codes.Code, msg string) *pb.BatchError {
+	return &pb.BatchError{Code: int32(code), Message: msg}
+}
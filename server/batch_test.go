@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sample-grpc-server/database"
+	mock_database "sample-grpc-server/database/mock"
+	"sample-grpc-server/database/model"
+	"sample-grpc-server/pb"
+
+	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_BatchCreateArticles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := &pb.BatchCreateArticlesRequest{
+		Articles: []*pb.CreateArticleRequest{
+			{Title: "title1", Text: "text1"},
+			{Title: "", Text: "text2"},
+			{Title: "title3", Text: "text3"},
+		},
+	}
+
+	t.Run("リクエスト成功", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().BatchCreateArticles(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, p database.BatchCreateArticlesParams) (*database.BatchCreateArticlesResult, error) {
+				if len(p.Articles) != 2 {
+					t.Errorf("Expect: %v, Got: %v", 2, len(p.Articles))
+				}
+				return &database.BatchCreateArticlesResult{ArticleIDs: []int64{10, 11}}, nil
+			})
+
+		got, err := callBatchCreateArticles(req, db)
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if got.Results[0].ArticleId != 10 || got.Results[2].ArticleId != 11 {
+			t.Errorf("unexpected ids: %v", got.Results)
+		}
+
+		if code := got.Results[1].GetError().GetCode(); code != int32(codes.InvalidArgument) {
+			t.Errorf("Expect: %v, Got: %v", codes.InvalidArgument, code)
+		}
+	})
+
+	t.Run("all_or_nothing", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().BatchCreateArticles(gomock.Any(), gomock.Any()).Times(0)
+
+		r := &pb.BatchCreateArticlesRequest{Articles: req.Articles, AllOrNothing: true}
+
+		got, err := callBatchCreateArticles(r, db)
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		expect := []codes.Code{codes.Aborted, codes.InvalidArgument, codes.Aborted}
+		for i, result := range got.Results {
+			if result.GetError().GetCode() != int32(expect[i]) {
+				t.Errorf("Expect: %v, Got: %v", expect[i], result.GetError().GetCode())
+			}
+		}
+	})
+
+	t.Run("空のバッチ", func(t *testing.T) {
+		_, err := callBatchCreateArticles(&pb.BatchCreateArticlesRequest{}, nil)
+
+		if s, _ := status.FromError(err); s.Code() != codes.InvalidArgument {
+			t.Errorf("Expect: %v, Got: %v", codes.InvalidArgument, s.Code())
+		}
+	})
+
+	t.Run("データベースエラー", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().BatchCreateArticles(gomock.Any(), gomock.Any()).Return(nil, errors.New("some error"))
+
+		_, err := callBatchCreateArticles(req, db)
+
+		if s, _ := status.FromError(err); s.Code() != codes.Internal {
+			t.Errorf("Expect: %v, Got: %v", codes.Internal, s.Code())
+		}
+	})
+}
+
+func TestServer_BatchGetArticles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := mock_database.NewMockQuerier(ctrl)
+	db.EXPECT().BatchGetArticles(gomock.Any(), gomock.Any()).Return(&database.BatchGetArticlesResult{
+		Articles: []model.Article{{ID: 2, Title: "title2"}},
+	}, nil)
+
+	got, err := callBatchGetArticles(&pb.BatchGetArticlesRequest{ArticleIds: []int64{1, 2}}, db)
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	if code := got.Results[0].GetError().GetCode(); code != int32(codes.NotFound) {
+		t.Errorf("Expect: %v, Got: %v", codes.NotFound, code)
+	}
+
+	if got.Results[1].GetArticle().GetTitle() != "title2" {
+		t.Errorf("Expect: %v, Got: %v", "title2", got.Results[1].GetArticle().GetTitle())
+	}
+}
+
+func TestServer_BatchDeleteArticles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := &pb.BatchDeleteArticlesRequest{ArticleIds: []int64{1, 2}, AllOrNothing: true}
+
+	t.Run("一部が存在しない", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().BatchDeleteArticles(gomock.Any(), gomock.Any()).Return(&database.BatchDeleteArticlesResult{
+			MissingIDs: []int64{2},
+		}, nil)
+
+		got, err := callBatchDeleteArticles(req, db)
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if code := got.Results[0].GetError().GetCode(); code != int32(codes.Aborted) {
+			t.Errorf("Expect: %v, Got: %v", codes.Aborted, code)
+		}
+		if code := got.Results[1].GetError().GetCode(); code != int32(codes.NotFound) {
+			t.Errorf("Expect: %v, Got: %v", codes.NotFound, code)
+		}
+	})
+
+	t.Run("データベースのエラー", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().BatchDeleteArticles(gomock.Any(), gomock.Any()).Return(nil, errors.New("some error"))
+
+		_, err := callBatchDeleteArticles(req, db)
+		if status.Code(err) != codes.Internal {
+			t.Errorf("Expect: %v, Got: %v", codes.Internal, err)
+		}
+	})
+
+	t.Run("リクエスト成功", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().BatchDeleteArticles(gomock.Any(), gomock.Any()).Return(&database.BatchDeleteArticlesResult{
+			DeletedIDs: []int64{1, 2},
+		}, nil)
+
+		got, err := callBatchDeleteArticles(req, db)
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		for _, result := range got.Results {
+			if result.Error != nil {
+				t.Errorf("error should be nil: %v", result.Error)
+			}
+		}
+	})
+}
+
+func callBatchCreateArticles(req *pb.BatchCreateArticlesRequest, db database.Querier) (*pb.BatchCreateArticlesResponse, error) {
+	ctx := context.WithValue(context.Background(), KeyUserID, int64(1))
+	s := NewServer(db, nil, nil)
+
+	return s.BatchCreateArticles(ctx, req)
+}
+
+func callBatchGetArticles(req *pb.BatchGetArticlesRequest, db database.Querier) (*pb.BatchGetArticlesResponse, error) {
+	ctx := context.WithValue(context.Background(), KeyUserID, int64(1))
+	s := NewServer(db, nil, nil)
+
+	return s.BatchGetArticles(ctx, req)
+}
+
+func callBatchDeleteArticles(req *pb.BatchDeleteArticlesRequest, db database.Querier) (*pb.BatchDeleteArticlesResponse, error) {
+	ctx := context.WithValue(context.Background(), KeyUserID, int64(1))
+	s := NewServer(db, nil, nil)
+
+	return s.BatchDeleteArticles(ctx, req)
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+
+	"sample-grpc-server/collab"
+	"sample-grpc-server/pb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) EditArticle(stream pb.BackendService_EditArticleServer) error {
+	ctx := stream.Context()
+	userID := extractUserID(ctx)
+
+	req, err := stream.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+
+	join := req.GetJoin()
+	if join == nil {
+		return status.Error(codes.InvalidArgument, "the first message must be join")
+	}
+
+	client, revision, text, err := s.editor.Join(ctx, join.GetArticleId(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return status.Error(codes.NotFound, "article not found")
+		}
+		return status.Error(codes.Internal, "server error")
+	}
+	defer client.Leave()
+
+	if err := stream.Send(&pb.EditArticleResponse{
+		Payload: &pb.EditArticleResponse_Snapshot{
+			Snapshot: &pb.EditSnapshot{
+				ParticipantId: client.ID,
+				Revision:      revision,
+				Text:          text,
+			},
+		},
+	}); err != nil {
+		return err
+	}
+
+	recvErr := make(chan error, 1)
+	go func() {
+		recvErr <- receiveOperations(stream, client)
+	}()
+
+	for {
+		select {
+		case e, ok := <-client.Events():
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "too slow to receive operations")
+			}
+			if err := stream.Send(editEventToPB(e)); err != nil {
+				return err
+			}
+		case err := <-recvErr:
+			return err
+		}
+	}
+}
+
+func receiveOperations(stream pb.BackendService_EditArticleServer, client *collab.Client) error {
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		op := req.GetOperation()
+		if op == nil {
+			return status.Error(codes.InvalidArgument, "already joined")
+		}
+
+		if err := client.Submit(op.GetRevision(), operationFromPB(op.GetComponents())); err != nil {
+			switch {
+			case errors.Is(err, collab.ErrRevisionOutOfRange):
+				return status.Error(codes.FailedPrecondition, "revision out of range")
+			case errors.Is(err, collab.ErrInvalidOperation), errors.Is(err, collab.ErrLengthMismatch):
+				return status.Error(codes.InvalidArgument, "invalid operation")
+			case errors.Is(err, collab.ErrClientClosed):
+				return status.Error(codes.ResourceExhausted, "too slow to receive operations")
+			default:
+				return status.Error(codes.Internal, "server error")
+			}
+		}
+	}
+}
+
+func operationFromPB(components []*pb.OperationComponent) collab.Operation {
+	op := make(collab.Operation, 0, len(components))
+	for _, c := range components {
+		switch v := c.GetComponent().(type) {
+		case *pb.OperationComponent_Retain:
+			op = append(op, collab.Component{Retain: int(v.Retain)})
+		case *pb.OperationComponent_Insert:
+			op = append(op, collab.Component{Insert: v.Insert})
+		case *pb.OperationComponent_Delete:
+			op = append(op, collab.Component{Delete: int(v.Delete)})
+		default:
+			// 空の要素はNormalizeで不正な操作として扱う
+			op = append(op, collab.Component{})
+		}
+	}
+
+	return op
+}
+
+func operationToPB(op collab.Operation) []*pb.OperationComponent {
+	components := make([]*pb.OperationComponent, 0, len(op))
+	for _, c := range op {
+		switch {
+		case c.Retain > 0:
+			components = append(components, &pb.OperationComponent{
+				Component: &pb.OperationComponent_Retain{Retain: int64(c.Retain)},
+			})
+		case c.Insert != "":
+			components = append(components, &pb.OperationComponent{
+				Component: &pb.OperationComponent_Insert{Insert: c.Insert},
+			})
+		default:
+			components = append(components, &pb.OperationComponent{
+				Component: &pb.OperationComponent_Delete{Delete: int64(c.Delete)},
+			})
+		}
+	}
+
+	return components
+}
+
+func editEventToPB(e collab.Event) *pb.EditArticleResponse {
+	switch e.Type {
+	case collab.EventAck:
+		return &pb.EditArticleResponse{
+			Payload: &pb.EditArticleResponse_Ack{
+				Ack: &pb.EditAck{Revision: e.Revision},
+			},
+		}
+	case collab.EventPresence:
+		participants := make([]*pb.Participant, 0, len(e.Participants))
+		for _, p := range e.Participants {
+			participants = append(participants, &pb.Participant{
+				ParticipantId: p.ID,
+				UserId:        p.UserID,
+			})
+		}
+
+		return &pb.EditArticleResponse{
+			Payload: &pb.EditArticleResponse_Presence{
+				Presence: &pb.EditPresence{Participants: participants},
+			},
+		}
+	default:
+		return &pb.EditArticleResponse{
+			Payload: &pb.EditArticleResponse_Operation{
+				Operation: &pb.EditOperation{
+					ParticipantId: e.From.ID,
+					UserId:        e.From.UserID,
+					Operation: &pb.TextOperation{
+						Revision:   e.Revision,
+						Components: operationToPB(e.Operation),
+					},
+				},
+			},
+		}
+	}
+}
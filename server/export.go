@@ -0,0 +1,65 @@
+package server
+
+import (
+	"bufio"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/export"
+	"sample-grpc-server/pb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const exportChunkSize = 32 * 1024
+
+type exportStreamWriter struct {
+	stream pb.BackendService_ExportArticlesServer
+}
+
+func (w exportStreamWriter) Write(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		end := n + exportChunkSize
+		if end > len(p) {
+			end = len(p)
+		}
+
+		// Sendの後にpが再利用されてもよいようにコピーして送る
+		chunk := append([]byte(nil), p[n:end]...)
+		if err := w.stream.Send(&pb.ExportArticlesResponse{Chunk: chunk}); err != nil {
+			return n, err
+		}
+		n = end
+	}
+	return n, nil
+}
+
+func (s *Server) ExportArticles(_ *emptypb.Empty, stream pb.BackendService_ExportArticlesServer) error {
+	ctx := stream.Context()
+	userID := extractUserID(ctx)
+
+	dbResp, err := s.db.GetArticles(ctx, database.GetArticlesParams{UserID: userID})
+	if err != nil {
+		return status.Error(codes.Internal, "server error")
+	}
+
+	w := bufio.NewWriterSize(exportStreamWriter{stream: stream}, exportChunkSize)
+
+	if err := export.WriteArchive(w, dbResp.Articles, s.userLocation(ctx, userID)); err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return status.Error(codes.Internal, "server error")
+	}
+
+	if err := w.Flush(); err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return status.Error(codes.Internal, "server error")
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/pb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const importChunkSize = 100
+
+type importItem struct {
+	index int64
+	req   *pb.ImportArticlesRequest
+}
+
+func (s *Server) ImportArticles(stream pb.BackendService_ImportArticlesServer) error {
+	ctx := stream.Context()
+	userID := extractUserID(ctx)
+
+	resp := &pb.ImportArticlesResponse{}
+	seen := make(map[string]bool)
+	chunk := make([]importItem, 0, importChunkSize)
+
+	for index := int64(0); ; index++ {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if req.GetTitle() == "" {
+			resp.Failures = append(resp.Failures, importFailure(index, req, "title is required"))
+			continue
+		}
+
+		// 同じストリーム内で重複した外部IDは後続をスキップする
+		if req.ExternalId != nil {
+			if seen[req.GetExternalId()] {
+				resp.Skipped++
+				continue
+			}
+			seen[req.GetExternalId()] = true
+		}
+
+		chunk = append(chunk, importItem{index: index, req: req})
+		if len(chunk) == importChunkSize {
+			if err := s.importChunk(ctx, userID, chunk, resp); err != nil {
+				return err
+			}
+			chunk = chunk[:0]
+		}
+	}
+
+	if err := s.importChunk(ctx, userID, chunk, resp); err != nil {
+		return err
+	}
+
+	resp.Failed = int64(len(resp.Failures))
+
+	return stream.SendAndClose(resp)
+}
+
+func (s *Server) importChunk(ctx context.Context, userID int64, chunk []importItem, resp *pb.ImportArticlesResponse) error {
+	pending, err := s.excludeExisting(ctx, userID, chunk, resp)
+	if err != nil {
+		return err
+	}
+
+	for len(pending) > 0 {
+		params := database.BatchCreateArticlesParams{UserID: userID}
+		for _, item := range pending {
+			params.Articles = append(params.Articles, database.BatchCreateArticle{
+				ExternalID:  convNullString(item.req.ExternalId),
+				Title:       item.req.GetTitle(),
+				Description: convNullString(item.req.Description),
+				Text:        item.req.GetText(),
+			})
+		}
+
+		// チャンクごとに1トランザクションで登録し、失敗したチャンクだけを失敗として報告する
+		_, err := s.db.BatchCreateArticles(ctx, params)
+		if err == nil {
+			resp.Created += int64(len(pending))
+			s.metrics.articlesCreated.WithLabelValues("import").Add(float64(len(pending)))
+			return nil
+		}
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+
+		// 同時に実行された他の取り込みが同じ外部IDを先に登録した場合は、それらをスキップしてやり直す
+		if database.IsUniqueViolation(err) {
+			remaining, err := s.excludeExisting(ctx, userID, pending, resp)
+			if err != nil {
+				return err
+			}
+			if len(remaining) < len(pending) {
+				pending = remaining
+				continue
+			}
+		}
+
+		for _, item := range pending {
+			resp.Failures = append(resp.Failures, importFailure(item.index, item.req, "database error"))
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// excludeExisting は外部IDが登録済みの記事をスキップした件数に数え、残りを返す
+func (s *Server) excludeExisting(ctx context.Context, userID int64, items []importItem, resp *pb.ImportArticlesResponse) ([]importItem, error) {
+	var externalIDs []string
+	for _, item := range items {
+		if item.req.ExternalId != nil {
+			externalIDs = append(externalIDs, item.req.GetExternalId())
+		}
+	}
+	if len(externalIDs) == 0 {
+		return items, nil
+	}
+
+	existing, err := s.db.GetArticleExternalIDs(ctx, database.GetArticleExternalIDsParams{
+		UserID:      userID,
+		ExternalIDs: externalIDs,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	exists := make(map[string]bool, len(existing.ExternalIDs))
+	for _, id := range existing.ExternalIDs {
+		exists[id] = true
+	}
+
+	var remaining []importItem
+	for _, item := range items {
+		if item.req.ExternalId != nil && exists[item.req.GetExternalId()] {
+			resp.Skipped++
+			continue
+		}
+		remaining = append(remaining, item)
+	}
+
+	return remaining, nil
+}
+
+func importFailure(index int64, req *pb.ImportArticlesRequest, reason string) *pb.ImportFailure {
+	return &pb.ImportFailure{
+		Index:      index,
+		ExternalId: req.ExternalId,
+		Reason:     reason,
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"sample-grpc-server/database"
+	mock_database "sample-grpc-server/database/mock"
+	"sample-grpc-server/pb"
+
+	"github.com/golang/mock/gomock"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+)
+
+type fakeImportStream struct {
+	grpc.ServerStream
+
+	ctx  context.Context
+	reqs []*pb.ImportArticlesRequest
+	resp *pb.ImportArticlesResponse
+}
+
+func (f *fakeImportStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeImportStream) Recv() (*pb.ImportArticlesRequest, error) {
+	if len(f.reqs) == 0 {
+		return nil, io.EOF
+	}
+	req := f.reqs[0]
+	f.reqs = f.reqs[1:]
+	return req, nil
+}
+
+func (f *fakeImportStream) SendAndClose(resp *pb.ImportArticlesResponse) error {
+	f.resp = resp
+	return nil
+}
+
+func TestServer_ImportArticles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ext := func(s string) *string { return &s }
+
+	reqs := []*pb.ImportArticlesRequest{
+		{ExternalId: ext("a"), Title: "title1"},
+		{ExternalId: ext("b"), Title: "title2"},
+		{ExternalId: ext("a"), Title: "duplicated"},
+		{Title: ""},
+		{Title: "title3"},
+	}
+
+	t.Run("リクエスト成功", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().GetArticleExternalIDs(gomock.Any(), gomock.Any()).Return(&database.GetArticleExternalIDsResult{
+			ExternalIDs: []string{"b"},
+		}, nil)
+		db.EXPECT().BatchCreateArticles(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, p database.BatchCreateArticlesParams) (*database.BatchCreateArticlesResult, error) {
+				if len(p.Articles) != 2 {
+					t.Errorf("Expect: %v, Got: %v", 2, len(p.Articles))
+				}
+				return &database.BatchCreateArticlesResult{ArticleIDs: []int64{1, 2}}, nil
+			})
+
+		stream := newFakeImportStream(reqs)
+		if err := NewServer(db, nil, nil).ImportArticles(stream); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if stream.resp.Created != 2 || stream.resp.Skipped != 2 || stream.resp.Failed != 1 {
+			t.Errorf("unexpected summary: %v", stream.resp)
+		}
+
+		if stream.resp.Failures[0].Index != 3 {
+			t.Errorf("Expect: %v, Got: %v", 3, stream.resp.Failures[0].Index)
+		}
+	})
+
+	t.Run("データベースエラー", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().GetArticleExternalIDs(gomock.Any(), gomock.Any()).Return(&database.GetArticleExternalIDsResult{}, nil)
+		db.EXPECT().BatchCreateArticles(gomock.Any(), gomock.Any()).Return(nil, errors.New("some error"))
+
+		stream := newFakeImportStream(reqs)
+		if err := NewServer(db, nil, nil).ImportArticles(stream); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if stream.resp.Created != 0 || stream.resp.Failed != 4 {
+			t.Errorf("unexpected summary: %v", stream.resp)
+		}
+	})
+
+	t.Run("同時に実行された取り込みが同じ外部IDを登録した", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		gomock.InOrder(
+			db.EXPECT().GetArticleExternalIDs(gomock.Any(), gomock.Any()).Return(&database.GetArticleExternalIDsResult{}, nil),
+			db.EXPECT().BatchCreateArticles(gomock.Any(), gomock.Any()).Return(nil, xerrors.Errorf("failed to create articles: %w", database.ErrUniqueViolation)),
+			db.EXPECT().GetArticleExternalIDs(gomock.Any(), database.GetArticleExternalIDsParams{UserID: 1, ExternalIDs: []string{"a", "b"}}).
+				Return(&database.GetArticleExternalIDsResult{ExternalIDs: []string{"a"}}, nil),
+			db.EXPECT().BatchCreateArticles(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, p database.BatchCreateArticlesParams) (*database.BatchCreateArticlesResult, error) {
+					if len(p.Articles) != 2 || p.Articles[0].ExternalID.String != "b" {
+						t.Errorf("registered article should be excluded: %+v", p.Articles)
+					}
+					return &database.BatchCreateArticlesResult{ArticleIDs: []int64{2, 3}}, nil
+				}),
+		)
+
+		stream := newFakeImportStream(reqs)
+		if err := NewServer(db, nil, nil).ImportArticles(stream); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if stream.resp.Created != 2 || stream.resp.Skipped != 2 || stream.resp.Failed != 1 {
+			t.Errorf("unexpected summary: %v", stream.resp)
+		}
+	})
+
+	t.Run("登録済みの外部IDがないのに一意制約違反", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().GetArticleExternalIDs(gomock.Any(), gomock.Any()).Return(&database.GetArticleExternalIDsResult{}, nil).Times(2)
+		db.EXPECT().BatchCreateArticles(gomock.Any(), gomock.Any()).Return(nil, database.ErrUniqueViolation)
+
+		stream := newFakeImportStream(reqs)
+		if err := NewServer(db, nil, nil).ImportArticles(stream); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if stream.resp.Created != 0 || stream.resp.Failed != 4 {
+			t.Errorf("unexpected summary: %v", stream.resp)
+		}
+	})
+}
+
+func newFakeImportStream(reqs []*pb.ImportArticlesRequest) *fakeImportStream {
+	return &fakeImportStream{
+		ctx:  context.WithValue(context.Background(), KeyUserID, int64(1)),
+		reqs: append([]*pb.ImportArticlesRequest(nil), reqs...),
+	}
+}
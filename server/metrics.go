@@ -0,0 +1,48 @@
+package server
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var _ prometheus.Collector = (*Metrics)(nil)
+
+// Metrics はユーザー登録やログイン、記事の作成の件数を数える。prometheus.Collectorとして登録して取得する
+type Metrics struct {
+	signups         prometheus.Counter
+	logins          prometheus.Counter
+	loginFailures   prometheus.Counter
+	articlesCreated *prometheus.CounterVec
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		signups: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "backend_signups_total",
+			Help: "Number of users signed up.",
+		}),
+		logins: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "backend_logins_total",
+			Help: "Number of successful logins.",
+		}),
+		loginFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "backend_login_failures_total",
+			Help: "Number of logins rejected because of a wrong email or password.",
+		}),
+		articlesCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "backend_articles_created_total",
+			Help: "Number of articles created, by the RPC used (create, batch, import).",
+		}, []string{"source"}),
+	}
+}
+
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.signups.Describe(ch)
+	m.logins.Describe(ch)
+	m.loginFailures.Describe(ch)
+	m.articlesCreated.Describe(ch)
+}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.signups.Collect(ch)
+	m.logins.Collect(ch)
+	m.loginFailures.Collect(ch)
+	m.articlesCreated.Collect(ch)
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"sample-grpc-server/database/memory"
+	"sample-grpc-server/pb"
+	"sample-grpc-server/service"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestServer_metrics(t *testing.T) {
+	m := NewMetrics()
+	s := NewServer(memory.NewQuerier(), service.NewHash(), service.NewAuth(), WithMetrics(m))
+
+	signUp := &pb.SignUpRequest{Email: "test@example.com", Password: "password"}
+	if _, err := s.SignUp(context.Background(), signUp); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	// 登録済みのメールアドレスは数えない
+	s.SignUp(context.Background(), signUp)
+
+	if _, err := s.Login(context.Background(), &pb.LoginRequest{Email: signUp.Email, Password: signUp.Password}); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	s.Login(context.Background(), &pb.LoginRequest{Email: signUp.Email, Password: "wrong"})
+	s.Login(context.Background(), &pb.LoginRequest{Email: "unknown@example.com", Password: "password"})
+
+	ctx := context.WithValue(context.Background(), KeyUserID, int64(1))
+	if _, err := s.CreateArticle(ctx, &pb.CreateArticleRequest{Title: "title", Text: "text"}); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	_, err := s.BatchCreateArticles(ctx, &pb.BatchCreateArticlesRequest{Articles: []*pb.CreateArticleRequest{
+		{Title: "first", Text: "text"},
+		{Title: "", Text: "text"},
+		{Title: "second", Text: "text"},
+	}})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		got    float64
+		expect float64
+	}{
+		{name: "ユーザー登録", got: testutil.ToFloat64(m.signups), expect: 1},
+		{name: "ログイン", got: testutil.ToFloat64(m.logins), expect: 1},
+		{name: "ログインの失敗", got: testutil.ToFloat64(m.loginFailures), expect: 2},
+		{name: "記事の作成", got: testutil.ToFloat64(m.articlesCreated.WithLabelValues("create")), expect: 1},
+		{name: "記事の一括作成", got: testutil.ToFloat64(m.articlesCreated.WithLabelValues("batch")), expect: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expect {
+				t.Errorf("Expect: %v, Got: %v", tt.expect, tt.got)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"time"
+
+	"sample-grpc-server/storage"
+	"sample-grpc-server/thumbnail"
+)
+
+const DefaultSessionLifetime = 24 * time.Hour
+
+type Option func(*Server)
+
+type AttachmentLimits struct {
+	MaxSize      int64
+	AllowedTypes []string
+}
+
+func WithBlobStore(store storage.BlobStore, limits AttachmentLimits) Option {
+	return func(s *Server) {
+		s.blobs = store
+		s.attachmentLimits = limits
+	}
+}
+
+// WithThumbnailGenerator はアップロードされた画像のサムネイルを非同期に生成する
+func WithThumbnailGenerator(g *thumbnail.Generator) Option {
+	return func(s *Server) {
+		s.thumbnails = g
+	}
+}
+
+// WithSessionLifetime はアクセストークンの有効期間を発行のたびにlifetimeから取得する
+func WithSessionLifetime(lifetime func() time.Duration) Option {
+	return func(s *Server) {
+		s.sessionLifetime = lifetime
+	}
+}
+
+// WithMetrics はユーザー登録やログイン、記事の作成の件数をmに記録する
+func WithMetrics(m *Metrics) Option {
+	return func(s *Server) {
+		s.metrics = m
+	}
+}
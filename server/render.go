@@ -0,0 +1,25 @@
+package server
+
+import (
+	"context"
+
+	"sample-grpc-server/pb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxPreviewSize = 1 << 20
+
+func (s *Server) RenderPreview(_ context.Context, req *pb.RenderPreviewRequest) (*pb.RenderPreviewResponse, error) {
+	if len(req.GetText()) > maxPreviewSize {
+		return nil, status.Errorf(codes.InvalidArgument, "text must be %d bytes or less", maxPreviewSize)
+	}
+
+	html, err := s.renderer.Render(req.GetText())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	return &pb.RenderPreviewResponse{Html: html}, nil
+}
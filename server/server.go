@@ -6,11 +6,16 @@ import (
 	"errors"
 	"time"
 
+	"sample-grpc-server/collab"
 	"sample-grpc-server/database"
+	"sample-grpc-server/database/model"
+	"sample-grpc-server/logger"
+	"sample-grpc-server/markdown"
 	"sample-grpc-server/pb"
 	"sample-grpc-server/service"
+	"sample-grpc-server/storage"
+	"sample-grpc-server/thumbnail"
 
-	"github.com/go-sql-driver/mysql"
 	"golang.org/x/xerrors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -24,22 +29,44 @@ type userID int
 
 const (
 	KeyUserID userID = iota
+	KeyAccessToken
 )
 
 type Server struct {
 	pb.BackendServiceServer
 
-	db   database.Querier
-	hash service.Hasher
-	auth service.Auther
+	db       database.Querier
+	hash     service.Hasher
+	auth     service.Auther
+	editor   *collab.Hub
+	renderer *markdown.Cache
+
+	blobs            storage.BlobStore
+	attachmentLimits AttachmentLimits
+	thumbnails       *thumbnail.Generator
+
+	sessionLifetime func() time.Duration
+	metrics         *Metrics
 }
 
-func NewServer(db database.Querier, hash service.Hasher, auth service.Auther) *Server {
-	return &Server{
-		db:   db,
-		hash: hash,
-		auth: auth,
+func NewServer(db database.Querier, hash service.Hasher, auth service.Auther, opts ...Option) *Server {
+	s := &Server{
+		db:       db,
+		hash:     hash,
+		auth:     auth,
+		editor:   collab.NewHub(db, collab.DefaultFlushInterval),
+		renderer: markdown.NewCache(markdown.NewMarkdown(), markdown.DefaultCacheSize),
+
+		sessionLifetime: func() time.Duration { return DefaultSessionLifetime },
+		// 登録しなければ数えるだけで公開されない
+		metrics: NewMetrics(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 func (s *Server) HelloWorld(_ context.Context, _ *emptypb.Empty) (*pb.HelloWorldResponse, error) {
@@ -58,30 +85,33 @@ func (s *Server) SignUp(ctx context.Context, req *pb.SignUpRequest) (*pb.SignUpR
 		Password: hash,
 	}
 
-	dbResp, err := s.db.SignUp(ctx, params)
-	if err != nil {
-		err = errors.Unwrap(err)
-		if mysqlErr, ok := err.(*mysql.MySQLError); ok {
-			switch mysqlErr.Number {
-			case 1062:
-				return nil, status.Error(codes.AlreadyExists, "the email is already registered")
-			}
-		}
-		return nil, status.Error(codes.Internal, "database error")
-	}
-
 	token, err := s.auth.CreateAccessToken()
 	if err != nil {
 		return nil, status.Error(codes.Internal, "server error")
 	}
 
-	if err := s.db.CreateSession(ctx, database.CreateSessionParams{
-		AccessToken: token,
-		UserID:      dbResp.UserID,
-	}); err != nil {
-		return nil, status.Error(codes.Internal, "server error")
+	// セッションを作成できなかった場合にユーザーだけが登録されないよう同じトランザクションで実行する
+	err = s.db.WithTx(ctx, func(q database.Querier) error {
+		dbResp, err := q.SignUp(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		return q.CreateSession(ctx, database.CreateSessionParams{
+			AccessToken: token,
+			UserID:      dbResp.UserID,
+			ExpiredAt:   time.Now().Add(s.sessionLifetime()),
+		})
+	})
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return nil, status.Error(codes.AlreadyExists, "the email is already registered")
+		}
+		return nil, status.Error(codes.Internal, "database error")
 	}
 
+	s.metrics.signups.Inc()
+
 	return &pb.SignUpResponse{AccessToken: token}, nil
 }
 
@@ -93,6 +123,7 @@ func (s *Server) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResp
 	dbResp, err := s.db.Login(ctx, params)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
+			s.metrics.loginFailures.Inc()
 			return nil, status.Error(codes.InvalidArgument, "invalid email or password")
 		}
 
@@ -105,6 +136,7 @@ func (s *Server) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResp
 	}
 
 	if !match {
+		s.metrics.loginFailures.Inc()
 		return nil, status.Error(codes.InvalidArgument, "invalid email or password")
 	}
 
@@ -116,13 +148,27 @@ func (s *Server) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResp
 	if err := s.db.CreateSession(ctx, database.CreateSessionParams{
 		AccessToken: token,
 		UserID:      dbResp.UserID,
+		ExpiredAt:   time.Now().Add(s.sessionLifetime()),
 	}); err != nil {
 		return nil, status.Error(codes.Internal, "server error")
 	}
 
+	s.metrics.logins.Inc()
+
 	return &pb.LoginResponse{AccessToken: token}, nil
 }
 
+// Logout はリクエストに使われたアクセストークンを失効させる
+func (s *Server) Logout(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	token, _ := ctx.Value(KeyAccessToken).(string)
+
+	if err := s.db.DeleteSession(ctx, database.DeleteSessionParams{AccessToken: token}); err != nil {
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
 func (s *Server) CreateArticle(ctx context.Context, req *pb.CreateArticleRequest) (*pb.CreateArticleResponse, error) {
 	userID := extractUserID(ctx)
 
@@ -138,14 +184,35 @@ func (s *Server) CreateArticle(ctx context.Context, req *pb.CreateArticleRequest
 		return nil, status.Error(codes.Internal, "server error")
 	}
 
+	s.metrics.articlesCreated.WithLabelValues("create").Inc()
+
 	return &pb.CreateArticleResponse{ArticleId: dbResp.ArticleID}, nil
 }
 
-func (s *Server) GetArticles(ctx context.Context, _ *emptypb.Empty) (*pb.GetArticlesResponse, error) {
+func (s *Server) GetArticles(ctx context.Context, req *pb.GetArticlesRequest) (*pb.GetArticlesResponse, error) {
 	userID := extractUserID(ctx)
 
 	params := database.GetArticlesParams{UserID: userID}
 
+	switch req.GetSortBy() {
+	case pb.ArticleSortField_ARTICLE_SORT_FIELD_UNSPECIFIED, pb.ArticleSortField_ARTICLE_SORT_FIELD_CREATED_AT:
+		params.SortBy = database.ArticleSortCreatedAt
+	case pb.ArticleSortField_ARTICLE_SORT_FIELD_UPDATED_AT:
+		params.SortBy = database.ArticleSortUpdatedAt
+	case pb.ArticleSortField_ARTICLE_SORT_FIELD_TITLE:
+		params.SortBy = database.ArticleSortTitle
+	default:
+		return nil, status.Error(codes.InvalidArgument, "unknown sort field")
+	}
+
+	switch req.GetOrder() {
+	case pb.SortOrder_SORT_ORDER_UNSPECIFIED, pb.SortOrder_SORT_ORDER_DESC:
+	case pb.SortOrder_SORT_ORDER_ASC:
+		params.Ascending = true
+	default:
+		return nil, status.Error(codes.InvalidArgument, "unknown sort order")
+	}
+
 	dbResp, err := s.db.GetArticles(ctx, params)
 	if err != nil {
 		return nil, status.Error(codes.Internal, "server error")
@@ -154,13 +221,11 @@ func (s *Server) GetArticles(ctx context.Context, _ *emptypb.Empty) (*pb.GetArti
 	resp := &pb.GetArticlesResponse{}
 
 	for _, article := range dbResp.Articles {
-		resp.Articles = append(resp.Articles, &pb.Article{
-			ArticleId:   article.ID,
-			Title:       article.Title,
-			Description: stringPtr(article.Description),
-			Text:        article.Text,
-			CreatedAt:   timestampPtr(article.CreatedAt),
-		})
+		resp.Articles = append(resp.Articles, s.articleToPB(article))
+	}
+
+	if err := s.setAttachments(ctx, userID, resp.Articles...); err != nil {
+		return nil, status.Error(codes.Internal, "server error")
 	}
 
 	return resp, nil
@@ -183,13 +248,11 @@ func (s *Server) GetArticle(ctx context.Context, req *pb.GetArticleRequest) (*pb
 	}
 
 	resp := &pb.GetArticleResponse{
-		Article: &pb.Article{
-			ArticleId:   dbResp.Article.ID,
-			Title:       dbResp.Article.Title,
-			Description: stringPtr(dbResp.Article.Description),
-			Text:        dbResp.Article.Text,
-			CreatedAt:   timestampPtr(dbResp.Article.CreatedAt),
-		},
+		Article: s.articleToPB(dbResp.Article),
+	}
+
+	if err := s.setAttachments(ctx, userID, resp.Article); err != nil {
+		return nil, status.Error(codes.Internal, "server error")
 	}
 
 	return resp, nil
@@ -211,6 +274,9 @@ func (s *Server) UpdateArticle(ctx context.Context, req *pb.UpdateArticleRequest
 		return nil, status.Errorf(codes.Aborted, "updating article is aborted")
 	}
 
+	// 編集セッション中の記事は、次の保存で更新前の本文に戻さないようセッションの本文も置き換える
+	s.editor.Replace(params.ArticleID, userID, params.Text)
+
 	return &emptypb.Empty{}, nil
 }
 
@@ -230,6 +296,31 @@ func (s *Server) DeleteArticle(ctx context.Context, req *pb.DeleteArticleRequest
 	return &emptypb.Empty{}, nil
 }
 
+func (s *Server) articleToPB(article model.Article) *pb.Article {
+	a := &pb.Article{
+		ArticleId:   article.ID,
+		Title:       article.Title,
+		Description: stringPtr(article.Description),
+		Text:        article.Text,
+		CreatedAt:   timestampPtr(article.CreatedAt),
+		UpdatedAt:   timestampPtr(article.UpdatedAt),
+	}
+
+	if article.DeletedAt.Valid {
+		a.DeletedAt = timestampPtr(article.DeletedAt.Time)
+	}
+
+	// 描画に失敗しても記事自体は返す
+	html, err := s.renderer.RenderArticle(article.ID, article.Text)
+	if err != nil {
+		logger.Errorf("failed to render article %d: %v", article.ID, err)
+		return a
+	}
+	a.RenderedHtml = &html
+
+	return a
+}
+
 func extractUserID(ctx context.Context) int64 {
 	IDStr := ctx.Value(KeyUserID)
 
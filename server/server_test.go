@@ -10,12 +10,15 @@ import (
 	"time"
 
 	"sample-grpc-server/database"
+	"sample-grpc-server/database/memory"
 	mock_database "sample-grpc-server/database/mock"
 	"sample-grpc-server/pb"
 	"sample-grpc-server/service"
 	mock_service "sample-grpc-server/service/mock"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/golang/mock/gomock"
+	"golang.org/x/xerrors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -53,6 +56,7 @@ func TestServer_SignUp(t *testing.T) {
 		hash.EXPECT().CreateHash(gomock.Any()).Return("hash", nil)
 
 		db := mock_database.NewMockQuerier(ctrl)
+		expectTx(db)
 		db.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(&database.SignUpResult{UserID: 1}, nil)
 		db.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil)
 
@@ -99,9 +103,13 @@ func TestServer_SignUp(t *testing.T) {
 			hash.EXPECT().CreateHash(gomock.Any()).Return("hash", nil)
 
 			db := mock_database.NewMockQuerier(ctrl)
+			expectTx(db)
 			db.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(nil, errors.New("some error"))
 
-			_, err := callSignUp(req, db, hash, nil)
+			auth := mock_service.NewMockAuther(ctrl)
+			auth.EXPECT().CreateAccessToken().Return("access_token", nil)
+
+			_, err := callSignUp(req, db, hash, auth)
 
 			if err == nil {
 				t.Errorf("err should not be nil: %v", err)
@@ -119,6 +127,7 @@ func TestServer_SignUp(t *testing.T) {
 			hash.EXPECT().CreateHash(gomock.Any()).Return("hash", nil)
 
 			db := mock_database.NewMockQuerier(ctrl)
+			expectTx(db)
 			db.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(&database.SignUpResult{UserID: 1}, nil)
 			db.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(errors.New("some error"))
 
@@ -139,12 +148,29 @@ func TestServer_SignUp(t *testing.T) {
 		})
 	})
 
+	t.Run("登録済みのメールアドレス", func(t *testing.T) {
+		hash := mock_service.NewMockHasher(ctrl)
+		hash.EXPECT().CreateHash(gomock.Any()).Return("hash", nil)
+
+		db := mock_database.NewMockQuerier(ctrl)
+		expectTx(db)
+		db.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(nil, xerrors.Errorf("failed to sign up: %w", &mysql.MySQLError{Number: 1062}))
+
+		auth := mock_service.NewMockAuther(ctrl)
+		auth.EXPECT().CreateAccessToken().Return("access_token", nil)
+
+		_, err := callSignUp(req, db, hash, auth)
+
+		if s, ok := status.FromError(err); !ok || s.Code() != codes.AlreadyExists {
+			t.Errorf("Expect: %v, Got: %v", codes.AlreadyExists, err)
+		}
+	})
+
 	t.Run("アクセストークン生成エラー", func(t *testing.T) {
 		hash := mock_service.NewMockHasher(ctrl)
 		hash.EXPECT().CreateHash(gomock.Any()).Return("hash", nil)
 
 		db := mock_database.NewMockQuerier(ctrl)
-		db.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(&database.SignUpResult{UserID: 1}, nil)
 
 		auth := mock_service.NewMockAuther(ctrl)
 		auth.EXPECT().CreateAccessToken().Return("", errors.New("some error"))
@@ -206,6 +232,36 @@ func TestServer_Login(t *testing.T) {
 		}
 	})
 
+	t.Run("設定された有効期間でセッションを作成", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().Login(gomock.Any(), gomock.Any()).Return(&database.LoginResult{
+			UserID:   1,
+			Password: "password",
+		}, nil)
+
+		hash := mock_service.NewMockHasher(ctrl)
+		hash.EXPECT().CompareHash(gomock.Any(), gomock.Any()).Return(true, nil)
+
+		auth := mock_service.NewMockAuther(ctrl)
+		auth.EXPECT().CreateAccessToken().Return("access_token", nil)
+
+		var got database.CreateSessionParams
+		db.EXPECT().CreateSession(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, p database.CreateSessionParams) error {
+				got = p
+				return nil
+			})
+
+		s := NewServer(db, hash, auth, WithSessionLifetime(func() time.Duration { return time.Hour }))
+		if _, err := s.Login(context.Background(), req); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if d := time.Until(got.ExpiredAt); d <= 59*time.Minute || d > time.Hour {
+			t.Errorf("session should expire in an hour: %v", got.ExpiredAt)
+		}
+	})
+
 	t.Run("データベースエラー", func(t *testing.T) {
 		t.Run("emailまたはpasswordが不一致", func(t *testing.T) {
 			db := mock_database.NewMockQuerier(ctrl)
@@ -345,6 +401,33 @@ func TestServer_Login(t *testing.T) {
 	})
 }
 
+func TestServer_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.WithValue(context.Background(), KeyUserID, int64(1))
+	ctx = context.WithValue(ctx, KeyAccessToken, "token")
+
+	t.Run("リクエスト成功", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().DeleteSession(gomock.Any(), database.DeleteSessionParams{AccessToken: "token"}).Return(nil)
+
+		if _, err := NewServer(db, nil, nil).Logout(ctx, &emptypb.Empty{}); err != nil {
+			t.Errorf("err should be nil: %v", err)
+		}
+	})
+
+	t.Run("データベースエラー", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().DeleteSession(gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+
+		_, err := NewServer(db, nil, nil).Logout(ctx, &emptypb.Empty{})
+		if status.Code(err) != codes.Internal {
+			t.Errorf("Expect: %v, Got: %v", codes.Internal, err)
+		}
+	})
+}
+
 func TestServer_CreateArticle(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -402,11 +485,12 @@ func TestServer_GetArticles(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	req := &emptypb.Empty{}
+	req := &pb.GetArticlesRequest{}
 
 	t.Run("リクエスト成功", func(t *testing.T) {
 		t.Run("multi Articles", func(t *testing.T) {
 			mockCreatedAt := time.Now()
+			mockUpdatedAt := mockCreatedAt.Add(time.Hour)
 			db := mock_database.NewMockQuerier(ctrl)
 			db.EXPECT().GetArticles(gomock.Any(), gomock.Any()).Return(&database.GetArticlesResult{
 				Articles: []model.Article{
@@ -416,6 +500,7 @@ func TestServer_GetArticles(t *testing.T) {
 						Title:     "test_title_1",
 						Text:      "test_text_1",
 						CreatedAt: mockCreatedAt,
+						UpdatedAt: mockUpdatedAt,
 					},
 					{
 						ID:        2,
@@ -423,6 +508,7 @@ func TestServer_GetArticles(t *testing.T) {
 						Title:     "test_title_2",
 						Text:      "test_text_2",
 						CreatedAt: mockCreatedAt,
+						UpdatedAt: mockUpdatedAt,
 					},
 				},
 			}, nil)
@@ -430,16 +516,20 @@ func TestServer_GetArticles(t *testing.T) {
 			expect := &pb.GetArticlesResponse{
 				Articles: []*pb.Article{
 					{
-						ArticleId: 1,
-						Title:     "test_title_1",
-						Text:      "test_text_1",
-						CreatedAt: timestampPtr(mockCreatedAt),
+						ArticleId:    1,
+						Title:        "test_title_1",
+						Text:         "test_text_1",
+						CreatedAt:    timestampPtr(mockCreatedAt),
+						UpdatedAt:    timestampPtr(mockUpdatedAt),
+						RenderedHtml: stringPtr("<p>test_text_1</p>\n"),
 					},
 					{
-						ArticleId: 2,
-						Title:     "test_title_2",
-						Text:      "test_text_2",
-						CreatedAt: timestampPtr(mockCreatedAt),
+						ArticleId:    2,
+						Title:        "test_title_2",
+						Text:         "test_text_2",
+						CreatedAt:    timestampPtr(mockCreatedAt),
+						UpdatedAt:    timestampPtr(mockUpdatedAt),
+						RenderedHtml: stringPtr("<p>test_text_2</p>\n"),
 					},
 				}}
 
@@ -458,6 +548,7 @@ func TestServer_GetArticles(t *testing.T) {
 
 		t.Run("one Article", func(t *testing.T) {
 			mockCreatedAt := time.Now()
+			mockUpdatedAt := mockCreatedAt.Add(time.Hour)
 			db := mock_database.NewMockQuerier(ctrl)
 			db.EXPECT().GetArticles(gomock.Any(), gomock.Any()).Return(&database.GetArticlesResult{
 				Articles: []model.Article{
@@ -467,6 +558,7 @@ func TestServer_GetArticles(t *testing.T) {
 						Title:     "test_title_1",
 						Text:      "test_text_1",
 						CreatedAt: mockCreatedAt,
+						UpdatedAt: mockUpdatedAt,
 					},
 				},
 			}, nil)
@@ -474,10 +566,12 @@ func TestServer_GetArticles(t *testing.T) {
 			expect := &pb.GetArticlesResponse{
 				Articles: []*pb.Article{
 					{
-						ArticleId: 1,
-						Title:     "test_title_1",
-						Text:      "test_text_1",
-						CreatedAt: timestampPtr(mockCreatedAt),
+						ArticleId:    1,
+						Title:        "test_title_1",
+						Text:         "test_text_1",
+						CreatedAt:    timestampPtr(mockCreatedAt),
+						UpdatedAt:    timestampPtr(mockUpdatedAt),
+						RenderedHtml: stringPtr("<p>test_text_1</p>\n"),
 					},
 				}}
 
@@ -516,6 +610,55 @@ func TestServer_GetArticles(t *testing.T) {
 		})
 	})
 
+	t.Run("並び順の指定", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			req    *pb.GetArticlesRequest
+			expect database.GetArticlesParams
+		}{
+			{
+				name:   "指定なし",
+				req:    &pb.GetArticlesRequest{},
+				expect: database.GetArticlesParams{UserID: 1, SortBy: database.ArticleSortCreatedAt},
+			},
+			{
+				name:   "更新日時の昇順",
+				req:    &pb.GetArticlesRequest{SortBy: pb.ArticleSortField_ARTICLE_SORT_FIELD_UPDATED_AT, Order: pb.SortOrder_SORT_ORDER_ASC},
+				expect: database.GetArticlesParams{UserID: 1, SortBy: database.ArticleSortUpdatedAt, Ascending: true},
+			},
+			{
+				name:   "タイトルの降順",
+				req:    &pb.GetArticlesRequest{SortBy: pb.ArticleSortField_ARTICLE_SORT_FIELD_TITLE, Order: pb.SortOrder_SORT_ORDER_DESC},
+				expect: database.GetArticlesParams{UserID: 1, SortBy: database.ArticleSortTitle},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				db := mock_database.NewMockQuerier(ctrl)
+				db.EXPECT().GetArticles(gomock.Any(), tt.expect).Return(&database.GetArticlesResult{}, nil)
+
+				if _, err := callGetArticles(tt.req, db, nil, nil); err != nil {
+					t.Errorf("err should be nil: %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("不正な並び順", func(t *testing.T) {
+		for _, req := range []*pb.GetArticlesRequest{
+			{SortBy: pb.ArticleSortField(100)},
+			{Order: pb.SortOrder(100)},
+		} {
+			db := mock_database.NewMockQuerier(ctrl)
+
+			_, err := callGetArticles(req, db, nil, nil)
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("Expect: %v, Got: %v", codes.InvalidArgument, err)
+			}
+		}
+	})
+
 	t.Run("データベースエラー", func(t *testing.T) {
 		db := mock_database.NewMockQuerier(ctrl)
 		db.EXPECT().GetArticles(gomock.Any(), gomock.Any()).Return(nil, errors.New("some error"))
@@ -543,6 +686,7 @@ func TestServer_GetArticle(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCreatedAt := time.Now()
+	mockUpdatedAt := mockCreatedAt.Add(time.Hour)
 	req := &pb.GetArticleRequest{
 		ArticleId: 1,
 	}
@@ -551,10 +695,12 @@ func TestServer_GetArticle(t *testing.T) {
 
 		expect := &pb.GetArticleResponse{
 			Article: &pb.Article{
-				ArticleId: 1,
-				Title:     "test_title",
-				Text:      "test_text",
-				CreatedAt: timestampPtr(mockCreatedAt),
+				ArticleId:    1,
+				Title:        "test_title",
+				Text:         "test_text",
+				CreatedAt:    timestampPtr(mockCreatedAt),
+				UpdatedAt:    timestampPtr(mockUpdatedAt),
+				RenderedHtml: stringPtr("<p>test_text</p>\n"),
 			},
 		}
 
@@ -566,6 +712,7 @@ func TestServer_GetArticle(t *testing.T) {
 				Title:     "test_title",
 				Text:      "test_text",
 				CreatedAt: mockCreatedAt,
+				UpdatedAt: mockUpdatedAt,
 			},
 		}, nil)
 
@@ -831,6 +978,13 @@ func callHello(req *emptypb.Empty, db database.Querier, hash service.Hasher, aut
 	return s.HelloWorld(ctx, req)
 }
 
+// expectTx はWithTxに渡された関数をトランザクションを使わずにそのまま実行させる
+func expectTx(db *mock_database.MockQuerier) {
+	db.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(database.Querier) error) error {
+		return fn(db)
+	})
+}
+
 func callSignUp(req *pb.SignUpRequest, db database.Querier, hash service.Hasher, auth service.Auther) (*pb.SignUpResponse, error) {
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, KeyUserID, int64(1))
@@ -855,7 +1009,7 @@ func callCreateArticle(req *pb.CreateArticleRequest, db database.Querier, hash s
 	return s.CreateArticle(ctx, req)
 }
 
-func callGetArticles(req *emptypb.Empty, db database.Querier, hash service.Hasher, auth service.Auther) (*pb.GetArticlesResponse, error) {
+func callGetArticles(req *pb.GetArticlesRequest, db database.Querier, hash service.Hasher, auth service.Auther) (*pb.GetArticlesResponse, error) {
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, KeyUserID, int64(1))
 	s := NewServer(db, hash, auth)
@@ -886,3 +1040,52 @@ func callDeleteArticle(req *pb.DeleteArticleRequest, db database.Querier, hash s
 
 	return s.DeleteArticle(ctx, req)
 }
+
+// モックではなくメモリ上のQuerierを使い、一連の呼び出しでデータの整合性が保たれるか確認する
+func TestServer_withMemoryQuerier(t *testing.T) {
+	s := NewServer(memory.NewQuerier(), service.NewHash(), service.NewAuth())
+
+	signUp := &pb.SignUpRequest{Email: "test@example.com", Password: "password"}
+	if _, err := s.SignUp(context.Background(), signUp); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	if _, err := s.SignUp(context.Background(), signUp); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Expect: %v, Got: %v", codes.AlreadyExists, err)
+	}
+
+	login, err := s.Login(context.Background(), &pb.LoginRequest{Email: signUp.Email, Password: signUp.Password})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if login.AccessToken == "" {
+		t.Error("access token should not be empty")
+	}
+
+	ctx := context.WithValue(context.Background(), KeyUserID, int64(1))
+
+	var ids []int64
+	for _, title := range []string{"first", "second"} {
+		resp, err := s.CreateArticle(ctx, &pb.CreateArticleRequest{Title: title, Text: "text"})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		ids = append(ids, resp.ArticleId)
+	}
+
+	if _, err := s.DeleteArticle(ctx, &pb.DeleteArticleRequest{ArticleId: ids[0]}); err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	if _, err := s.GetArticle(ctx, &pb.GetArticleRequest{ArticleId: ids[0]}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expect: %v, Got: %v", codes.NotFound, err)
+	}
+
+	articles, err := s.GetArticles(ctx, &pb.GetArticlesRequest{})
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+	if len(articles.Articles) != 1 || articles.Articles[0].Title != "second" {
+		t.Errorf("only the second article should remain: %v", articles.Articles)
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/pb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *Server) GetUserSettings(ctx context.Context, _ *emptypb.Empty) (*pb.UserSettings, error) {
+	userID := extractUserID(ctx)
+
+	dbResp, err := s.db.GetUserTimezone(ctx, database.GetUserTimezoneParams{UserID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	return &pb.UserSettings{Timezone: dbResp.Timezone}, nil
+}
+
+func (s *Server) UpdateUserSettings(ctx context.Context, req *pb.UpdateUserSettingsRequest) (*pb.UserSettings, error) {
+	userID := extractUserID(ctx)
+
+	// Localや空文字はサーバーの環境に依存するため受け付けない
+	if req.Timezone == "" || req.Timezone == "Local" {
+		return nil, status.Error(codes.InvalidArgument, "timezone must be an IANA time zone name")
+	}
+	loc, err := time.LoadLocation(req.Timezone)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown timezone: %s", req.Timezone)
+	}
+
+	if err := s.db.UpdateUserTimezone(ctx, database.UpdateUserTimezoneParams{
+		UserID:   userID,
+		Timezone: loc.String(),
+	}); err != nil {
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	return &pb.UserSettings{Timezone: loc.String()}, nil
+}
+
+// userLocation はユーザーが設定したタイムゾーンを返す。取得できない場合はUTCを使う
+func (s *Server) userLocation(ctx context.Context, userID int64) *time.Location {
+	dbResp, err := s.db.GetUserTimezone(ctx, database.GetUserTimezoneParams{UserID: userID})
+	if err != nil {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(dbResp.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
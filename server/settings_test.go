@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"sample-grpc-server/database"
+	mock_database "sample-grpc-server/database/mock"
+	"sample-grpc-server/pb"
+
+	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestServer_GetUserSettings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.WithValue(context.Background(), KeyUserID, int64(1))
+
+	t.Run("リクエスト成功", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().GetUserTimezone(gomock.Any(), database.GetUserTimezoneParams{UserID: 1}).
+			Return(&database.GetUserTimezoneResult{Timezone: "Asia/Tokyo"}, nil)
+
+		resp, err := NewServer(db, nil, nil).GetUserSettings(ctx, &emptypb.Empty{})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if resp.Timezone != "Asia/Tokyo" {
+			t.Errorf("Expect: %v, Got: %v", "Asia/Tokyo", resp.Timezone)
+		}
+	})
+
+	t.Run("ユーザーが存在しない", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().GetUserTimezone(gomock.Any(), gomock.Any()).Return(nil, sql.ErrNoRows)
+
+		_, err := NewServer(db, nil, nil).GetUserSettings(ctx, &emptypb.Empty{})
+		if s, _ := status.FromError(err); s.Code() != codes.NotFound {
+			t.Errorf("Expect: %v, Got: %v", codes.NotFound, s.Code())
+		}
+	})
+}
+
+func TestServer_UpdateUserSettings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.WithValue(context.Background(), KeyUserID, int64(1))
+
+	t.Run("リクエスト成功", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().UpdateUserTimezone(gomock.Any(), database.UpdateUserTimezoneParams{
+			UserID:   1,
+			Timezone: "America/New_York",
+		}).Return(nil)
+
+		resp, err := NewServer(db, nil, nil).UpdateUserSettings(ctx, &pb.UpdateUserSettingsRequest{Timezone: "America/New_York"})
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if resp.Timezone != "America/New_York" {
+			t.Errorf("Expect: %v, Got: %v", "America/New_York", resp.Timezone)
+		}
+	})
+
+	t.Run("不正なタイムゾーン", func(t *testing.T) {
+		for _, tz := range []string{"", "Local", "Mars/Olympus_Mons"} {
+			db := mock_database.NewMockQuerier(ctrl)
+
+			_, err := NewServer(db, nil, nil).UpdateUserSettings(ctx, &pb.UpdateUserSettingsRequest{Timezone: tz})
+			if s, _ := status.FromError(err); s.Code() != codes.InvalidArgument {
+				t.Errorf("%q: Expect: %v, Got: %v", tz, codes.InvalidArgument, s.Code())
+			}
+		}
+	})
+
+	t.Run("データベースエラー", func(t *testing.T) {
+		db := mock_database.NewMockQuerier(ctrl)
+		db.EXPECT().UpdateUserTimezone(gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+
+		_, err := NewServer(db, nil, nil).UpdateUserSettings(ctx, &pb.UpdateUserSettingsRequest{Timezone: "UTC"})
+		if s, _ := status.FromError(err); s.Code() != codes.Internal {
+			t.Errorf("Expect: %v, Got: %v", codes.Internal, s.Code())
+		}
+	})
+}
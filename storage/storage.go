@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: blob not found")
+	ErrInvalidKey = errors.New("storage: invalid key")
+)
+
+//go:generate mockgen -source=$GOFILE -destination=mock/mock_$GOFILE
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type LocalBlobStore struct {
+	dir string
+}
+
+func NewLocalBlobStore(dir string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, xerrors.Errorf("failed to create blob directory: %v", err)
+	}
+
+	return &LocalBlobStore{dir: dir}, nil
+}
+
+// Put は一時ファイルに書き込んでからリネームするため、書き込み途中のファイルが読まれることはない
+func (s *LocalBlobStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, xerrors.Errorf("failed to create blob directory: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, xerrors.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, contextReader{ctx: ctx, r: r})
+	if err != nil {
+		tmp.Close()
+		return 0, xerrors.Errorf("failed to write blob: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return 0, xerrors.Errorf("failed to close blob: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, xerrors.Errorf("failed to store blob: %v", err)
+	}
+
+	return n, nil
+}
+
+func (s *LocalBlobStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, xerrors.Errorf("failed to open blob %s: %w", key, ErrNotFound)
+		}
+		return nil, xerrors.Errorf("failed to open blob %s: %v", key, err)
+	}
+
+	return f, nil
+}
+
+func (s *LocalBlobStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return xerrors.Errorf("failed to delete blob %s: %v", key, err)
+	}
+
+	return nil
+}
+
+// path はキーを保存先ディレクトリ配下のパスに変換する。ディレクトリの外を指すキーは拒否する
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if key == "" || filepath.IsAbs(key) || strings.Contains(key, `\`) {
+		return "", ErrInvalidKey
+	}
+
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(s.dir, clean), nil
+}
+
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	t.Run("保存と取得", func(t *testing.T) {
+		n, err := s.Put(ctx, "a/b", strings.NewReader("hello"))
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if n != 5 {
+			t.Errorf("Expect: %v, Got: %v", 5, n)
+		}
+
+		rc, err := s.Get(ctx, "a/b")
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		defer rc.Close()
+
+		got, _ := io.ReadAll(rc)
+		if string(got) != "hello" {
+			t.Errorf("Expect: %v, Got: %v", "hello", string(got))
+		}
+	})
+
+	t.Run("削除", func(t *testing.T) {
+		if _, err := s.Put(ctx, "deleted", strings.NewReader("x")); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if err := s.Delete(ctx, "deleted"); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		if _, err := s.Get(ctx, "deleted"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expect: %v, Got: %v", ErrNotFound, err)
+		}
+	})
+
+	t.Run("不正なキー", func(t *testing.T) {
+		for _, key := range []string{"", "/etc/passwd", "../outside", "a/../../outside"} {
+			if _, err := s.Put(ctx, key, strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("%q: Expect: %v, Got: %v", key, ErrInvalidKey, err)
+			}
+		}
+	})
+
+	t.Run("書き込みエラー", func(t *testing.T) {
+		_, err := s.Put(ctx, "broken", io.MultiReader(strings.NewReader("x"), errReader{}))
+		if err == nil {
+			t.Fatal("err should not be nil")
+		}
+
+		if _, err := s.Get(ctx, "broken"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("partial blob should not be stored: %v", err)
+		}
+	})
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("some error")
+}
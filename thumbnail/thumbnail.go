@@ -0,0 +1,241 @@
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sync"
+	"time"
+
+	"sample-grpc-server/database"
+	"sample-grpc-server/logger"
+	"sample-grpc-server/storage"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DefaultWorkers = 2
+
+	queueSize  = 128
+	jobTimeout = 30 * time.Second
+
+	// 展開後に巨大になる画像でメモリを使い切らないよう、デコード前に画素数を制限する
+	maxPixels = 40_000_000
+)
+
+var (
+	ErrUnsupported = errors.New("thumbnail: unsupported image format")
+	ErrTooLarge    = errors.New("thumbnail: image is too large")
+)
+
+// Job はサムネイルを生成する添付ファイル
+type Job struct {
+	AttachmentID int64
+	StorageKey   string
+	ContentType  string
+}
+
+type Generator struct {
+	db    database.Querier
+	blobs storage.BlobStore
+	sizes []int
+
+	mu     sync.RWMutex
+	closed bool
+	jobs   chan Job
+	wg     sync.WaitGroup
+}
+
+// NewGenerator はワーカーを起動する。停止するにはCloseを呼ぶ
+func NewGenerator(db database.Querier, blobs storage.BlobStore, sizes []int, workers int) *Generator {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	g := &Generator{
+		db:    db,
+		blobs: blobs,
+		sizes: sizes,
+		jobs:  make(chan Job, queueSize),
+	}
+
+	g.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go g.worker()
+	}
+
+	return g
+}
+
+// Supported はサムネイルを生成できるContent-Typeかを判定する
+func Supported(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	default:
+		return false
+	}
+}
+
+// Enqueue はジョブをキューに積む。キューが満杯か停止済みの場合はfalseを返す
+func (g *Generator) Enqueue(job Job) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if g.closed {
+		return false
+	}
+
+	select {
+	case g.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close は新しいジョブの受け付けを止め、キューに残ったジョブの完了を待つ
+func (g *Generator) Close() {
+	g.mu.Lock()
+	if !g.closed {
+		g.closed = true
+		close(g.jobs)
+	}
+	g.mu.Unlock()
+
+	g.wg.Wait()
+}
+
+func (g *Generator) worker() {
+	defer g.wg.Done()
+
+	for job := range g.jobs {
+		ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+		if err := g.Generate(ctx, job); err != nil {
+			logger.Errorf("failed to generate thumbnails of attachment %d: %v", job.AttachmentID, err)
+		}
+		cancel()
+	}
+}
+
+// Generate は設定された各サイズのサムネイルを生成して保存する
+func (g *Generator) Generate(ctx context.Context, job Job) error {
+	if !Supported(job.ContentType) {
+		return ErrUnsupported
+	}
+
+	rc, err := g.blobs.Get(ctx, job.StorageKey)
+	if err != nil {
+		return xerrors.Errorf("failed to open original: %w", err)
+	}
+	defer rc.Close()
+
+	src, err := decode(rc)
+	if err != nil {
+		return err
+	}
+
+	for _, size := range g.sizes {
+		dst := Resize(src, size)
+
+		var buf bytes.Buffer
+		contentType, err := encode(&buf, dst, job.ContentType)
+		if err != nil {
+			return err
+		}
+
+		key := fmt.Sprintf("thumbnails/%s/%d", job.StorageKey, size)
+		if _, err := g.blobs.Put(ctx, key, &buf); err != nil {
+			return xerrors.Errorf("failed to store thumbnail: %w", err)
+		}
+
+		if err := g.db.CreateThumbnail(ctx, database.CreateThumbnailParams{
+			AttachmentID: job.AttachmentID,
+			Size:         size,
+			Width:        dst.Bounds().Dx(),
+			Height:       dst.Bounds().Dy(),
+			ContentType:  contentType,
+			StorageKey:   key,
+		}); err != nil {
+			if err := g.blobs.Delete(ctx, key); err != nil {
+				logger.Warnf("failed to delete thumbnail %s: %v", key, err)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Resize は縦横比を保ったまま長辺がsize以下になるよう縮小する。拡大はしない
+func Resize(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	if w <= size && h <= size {
+		return src
+	}
+
+	if w >= h {
+		w, h = size, h*size/w
+	} else {
+		w, h = w*size/h, size
+	}
+	if w == 0 {
+		w = 1
+	}
+	if h == 0 {
+		h = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+
+	return dst
+}
+
+func decode(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read original: %v", err)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode image config: %w", ErrUnsupported)
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	// GIFアニメーションは先頭のフレームだけを使う
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to decode image: %v", err)
+	}
+
+	return img, nil
+}
+
+// encode はJPEGはJPEGのまま、透過を持ちうるPNGとGIFはPNGで書き出す
+func encode(w io.Writer, img image.Image, contentType string) (string, error) {
+	if contentType == "image/jpeg" {
+		if err := jpeg.Encode(w, img, &jpeg.Options{Quality: 85}); err != nil {
+			return "", xerrors.Errorf("failed to encode jpeg: %v", err)
+		}
+		return "image/jpeg", nil
+	}
+
+	if err := png.Encode(w, img); err != nil {
+		return "", xerrors.Errorf("failed to encode png: %v", err)
+	}
+	return "image/png", nil
+}
@@ -0,0 +1,167 @@
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"sample-grpc-server/database"
+	mock_database "sample-grpc-server/database/mock"
+	"sample-grpc-server/storage"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		size          int
+		expectW       int
+		expectH       int
+	}{
+		{name: "横長", width: 400, height: 200, size: 100, expectW: 100, expectH: 50},
+		{name: "縦長", width: 200, height: 400, size: 100, expectW: 50, expectH: 100},
+		{name: "拡大しない", width: 50, height: 20, size: 100, expectW: 50, expectH: 20},
+		{name: "極端な縦横比", width: 1000, height: 1, size: 10, expectW: 10, expectH: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Resize(image.NewRGBA(image.Rect(0, 0, tt.width, tt.height)), tt.size).Bounds()
+			if got.Dx() != tt.expectW || got.Dy() != tt.expectH {
+				t.Errorf("Expect: %dx%d, Got: %dx%d", tt.expectW, tt.expectH, got.Dx(), got.Dy())
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	img := image.NewRGBA(image.Rect(0, 0, 300, 150))
+	for x := 0; x < 300; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+
+	var pngData, jpegData bytes.Buffer
+	if err := png.Encode(&pngData, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpegData, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		expectType  string
+	}{
+		{name: "PNG", data: pngData.Bytes(), contentType: "image/png", expectType: "image/png"},
+		{name: "JPEG", data: jpegData.Bytes(), contentType: "image/jpeg", expectType: "image/jpeg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blobs, err := storage.NewLocalBlobStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("err should be nil: %v", err)
+			}
+			if _, err := blobs.Put(ctx, "original", bytes.NewReader(tt.data)); err != nil {
+				t.Fatalf("err should be nil: %v", err)
+			}
+
+			var created []database.CreateThumbnailParams
+			db := mock_database.NewMockQuerier(ctrl)
+			db.EXPECT().CreateThumbnail(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, p database.CreateThumbnailParams) error {
+					created = append(created, p)
+					return nil
+				}).Times(2)
+
+			g := &Generator{db: db, blobs: blobs, sizes: []int{100, 1000}}
+			if err := g.Generate(ctx, Job{AttachmentID: 1, StorageKey: "original", ContentType: tt.contentType}); err != nil {
+				t.Fatalf("err should be nil: %v", err)
+			}
+
+			if created[0].Width != 100 || created[0].Height != 50 || created[0].ContentType != tt.expectType {
+				t.Errorf("unexpected thumbnail: %+v", created[0])
+			}
+			if created[1].Width != 300 || created[1].Height != 150 {
+				t.Errorf("unexpected thumbnail: %+v", created[1])
+			}
+
+			rc, err := blobs.Get(ctx, created[0].StorageKey)
+			if err != nil {
+				t.Fatalf("err should be nil: %v", err)
+			}
+			defer rc.Close()
+
+			cfg, _, err := image.DecodeConfig(rc)
+			if err != nil {
+				t.Fatalf("err should be nil: %v", err)
+			}
+			if cfg.Width != 100 || cfg.Height != 50 {
+				t.Errorf("Expect: 100x50, Got: %dx%d", cfg.Width, cfg.Height)
+			}
+		})
+	}
+
+	t.Run("画像ではないデータ", func(t *testing.T) {
+		blobs, err := storage.NewLocalBlobStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+		if _, err := blobs.Put(ctx, "original", strings.NewReader("not an image")); err != nil {
+			t.Fatalf("err should be nil: %v", err)
+		}
+
+		g := &Generator{db: mock_database.NewMockQuerier(ctrl), blobs: blobs, sizes: []int{100}}
+		err = g.Generate(ctx, Job{AttachmentID: 1, StorageKey: "original", ContentType: "image/png"})
+		if !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Expect: %v, Got: %v", ErrUnsupported, err)
+		}
+	})
+}
+
+func TestGenerator_Close(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blobs, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("err should be nil: %v", err)
+	}
+
+	done := make(chan struct{})
+	g := NewGenerator(mock_database.NewMockQuerier(ctrl), blobs, []int{100}, 1)
+	if !g.Enqueue(Job{AttachmentID: 1, StorageKey: "missing", ContentType: "image/png"}) {
+		t.Fatal("job should be enqueued")
+	}
+
+	go func() {
+		g.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close should wait for queued jobs and return")
+	}
+
+	if g.Enqueue(Job{AttachmentID: 2}) {
+		t.Error("job should not be enqueued after Close")
+	}
+}